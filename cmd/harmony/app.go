@@ -12,10 +12,13 @@ import (
 	"github.com/undefined7887/harmony-backend/internal/config"
 	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
 	zaplog "github.com/undefined7887/harmony-backend/internal/infrastructure/log/zap"
+	"github.com/undefined7887/harmony-backend/internal/infrastructure/storage"
 	chatrepo "github.com/undefined7887/harmony-backend/internal/repository/chat"
+	filerepo "github.com/undefined7887/harmony-backend/internal/repository/file"
 	userrepo "github.com/undefined7887/harmony-backend/internal/repository/user"
 	"github.com/undefined7887/harmony-backend/internal/service/auth"
 	chatservice "github.com/undefined7887/harmony-backend/internal/service/chat"
+	fileservice "github.com/undefined7887/harmony-backend/internal/service/file"
 	"github.com/undefined7887/harmony-backend/internal/service/jwt"
 	userservice "github.com/undefined7887/harmony-backend/internal/service/user"
 	"github.com/undefined7887/harmony-backend/internal/third_party/centrifugo"
@@ -23,6 +26,7 @@ import (
 	"github.com/undefined7887/harmony-backend/internal/transport"
 	"github.com/undefined7887/harmony-backend/internal/transport/auth"
 	chattransport "github.com/undefined7887/harmony-backend/internal/transport/chat"
+	filetransport "github.com/undefined7887/harmony-backend/internal/transport/file"
 	usertransport "github.com/undefined7887/harmony-backend/internal/transport/user"
 )
 
@@ -35,6 +39,7 @@ func NewApp() *fx.App {
 		// Infrastructure
 		zaplog.Module,
 		mongodatabase.Module,
+		storage.Module,
 
 		// Third party
 		google.Module,
@@ -44,6 +49,7 @@ func NewApp() *fx.App {
 		userrepo.Module,
 		chatrepo.Module,
 		callrepo.Module,
+		filerepo.Module,
 
 		// Services
 		jwtservice.Module,
@@ -51,6 +57,7 @@ func NewApp() *fx.App {
 		userservice.Module,
 		chatservice.Module,
 		callservice.Module,
+		fileservice.Module,
 
 		// Transport
 		transport.Module,
@@ -58,6 +65,7 @@ func NewApp() *fx.App {
 		usertransport.Module,
 		chattransport.Module,
 		calltransport.Module,
+		filetransport.Module,
 	)
 }
 
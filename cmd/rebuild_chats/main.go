@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	stdlog "log"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
+	zaplog "github.com/undefined7887/harmony-backend/internal/infrastructure/log/zap"
+	chatrepo "github.com/undefined7887/harmony-backend/internal/repository/chat"
+)
+
+const (
+	StartTimeout = time.Second * 15
+	StopTimeout  = time.Second * 2
+
+	// Progress is logged every N chat list entries
+	progressInterval = 1000
+)
+
+// Regenerates chat lists of all users (chat_members collection) from messages and group members:
+//
+//	$ rebuild_chats -config ./config/config.yml
+func main() {
+	var (
+		logger         *zap.Logger
+		chatRepository chatdomain.ChatRepository
+	)
+
+	app := fx.New(
+		fx.NopLogger,
+
+		config.Module,
+
+		zaplog.Module,
+		mongodatabase.Module,
+
+		// Chat lists are not built yet, so startup check is skipped
+		chatrepo.RepositoryModule,
+
+		fx.Populate(&logger, &chatRepository),
+	)
+
+	startCtx, cancel := context.WithTimeout(context.Background(), StartTimeout)
+	defer cancel()
+
+	if err := app.Start(startCtx); err != nil {
+		stdlog.Fatalln(fmt.Errorf("failed to start: %v", err))
+	}
+
+	logger.Info("rebuilding chat lists")
+
+	var total int64
+
+	err := chatRepository.Rebuild(context.Background(), func(count int64) {
+		total = count
+
+		if count%progressInterval == 0 {
+			logger.Info("rebuilding chat lists", zap.Int64("count", count))
+		}
+	})
+
+	if err != nil {
+		logger.Error("failed to rebuild chat lists", zap.Error(err))
+	} else {
+		logger.Info("chat lists rebuilt", zap.Int64("count", total))
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), StopTimeout)
+	defer cancel()
+
+	if stopErr := app.Stop(stopCtx); stopErr != nil {
+		stdlog.Fatalln(fmt.Errorf("failed to stop: %v", stopErr))
+	}
+
+	if err != nil {
+		stdlog.Fatalln(err)
+	}
+}
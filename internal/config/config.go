@@ -1,7 +1,9 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"time"
 
@@ -24,6 +26,10 @@ type Config struct {
 	*Jwt        `yaml:"jwt"`
 	*Mongo      `yaml:"mongo"`
 	*Centrifugo `yaml:"centrifugo"`
+	*Chat       `yaml:"chat"`
+	*Call       `yaml:"call"`
+	*File       `yaml:"file"`
+	*Storage    `yaml:"storage"`
 }
 
 type App struct {
@@ -64,6 +70,90 @@ type Centrifugo struct {
 	ApiKey     string `yaml:"api_key"`
 }
 
+type Chat struct {
+	// Time after sending, while sender can delete message for all participants
+	DeleteWindow time.Duration `yaml:"delete_window"`
+
+	// Time after sending, while sender can edit message
+	EditWindow time.Duration `yaml:"edit_window"`
+
+	// Maximum number of resolved mentions per message, the rest of them are left as text
+	MentionsLimit int64 `yaml:"mentions_limit"`
+
+	// Maximum number of pinned messages per chat
+	PinsLimit int64 `yaml:"pins_limit"`
+
+	// Maximum number of chats, pinned by user
+	PinnedChatsLimit int64 `yaml:"pinned_chats_limit"`
+
+	// Maximum number of chat folders per user
+	FoldersLimit int64 `yaml:"folders_limit"`
+
+	// Maximum number of pending scheduled messages per user and how far they can be scheduled
+	ScheduledLimit    int64         `yaml:"scheduled_limit"`
+	ScheduledMaxDelay time.Duration `yaml:"scheduled_max_delay"`
+
+	// Due scheduled messages are checked every interval, claimed message is locked for lease time
+	ScheduledDispatchInterval time.Duration `yaml:"scheduled_dispatch_interval"`
+	ScheduledLeaseTime        time.Duration `yaml:"scheduled_lease_time"`
+
+	// Allowed lifetimes of messages in chats with disappearing messages
+	MessageTTLs []time.Duration `yaml:"message_ttls"`
+
+	// Expired messages are deleted every interval in batches of provided size
+	ReaperInterval  time.Duration `yaml:"reaper_interval"`
+	ReaperBatchSize int64         `yaml:"reaper_batch_size"`
+
+	// Delivery receipts are written to database in batches,
+	// batch is flushed when it's full or flush interval is passed
+	ReceiptsQueueSize     int           `yaml:"receipts_queue_size"`
+	ReceiptsBatchSize     int           `yaml:"receipts_batch_size"`
+	ReceiptsFlushInterval time.Duration `yaml:"receipts_flush_interval"`
+}
+
+type Call struct {
+	// Time, while peer can accept call, after that call is missed
+	RingingTimeout time.Duration `yaml:"ringing_timeout"`
+
+	// Stale requests and calls with offline participants are checked every interval
+	SweepInterval time.Duration `yaml:"sweep_interval"`
+}
+
+type File struct {
+	// Maximum file size in bytes
+	MaxSize int64 `yaml:"max_size"`
+
+	// Allowed MIME types, wildcards like 'image/*' are supported
+	AllowedTypes []string `yaml:"allowed_types"`
+
+	// Lifetime and signing secret of download urls
+	UrlLifetime time.Duration `yaml:"url_lifetime"`
+	UrlSecret   string        `yaml:"url_secret"`
+
+	// Longest side of generated image thumbnails in pixels
+	ThumbnailSizes []int `yaml:"thumbnail_sizes"`
+
+	// Number of concurrent processing workers and size of their queue
+	ProcessingWorkers   int `yaml:"processing_workers"`
+	ProcessingQueueSize int `yaml:"processing_queue_size"`
+}
+
+type Storage struct {
+	// One of: local, s3
+	Type string `yaml:"type"`
+
+	// Local storage settings
+	LocalPath string `yaml:"local_path"`
+
+	// S3 compatible storage settings
+	S3Endpoint  string `yaml:"s3_endpoint"`
+	S3Region    string `yaml:"s3_region"`
+	S3Bucket    string `yaml:"s3_bucket"`
+	S3AccessKey string `yaml:"s3_access_key"`
+	S3SecretKey string `yaml:"s3_secret_key"`
+	S3Secure    bool   `yaml:"s3_secure"`
+}
+
 func NewConfig() (Config, error) {
 	var config Config
 
@@ -71,9 +161,77 @@ func NewConfig() (Config, error) {
 		return Config{}, err
 	}
 
+	if err := config.validate(); err != nil {
+		return Config{}, err
+	}
+
 	return config, nil
 }
 
+// validate rejects settings, which can't be used at runtime
+func (c *Config) validate() error {
+	sections := []struct {
+		name    string
+		missing bool
+	}{
+		{"chat", c.Chat == nil},
+		{"call", c.Call == nil},
+		{"file", c.File == nil},
+		{"storage", c.Storage == nil},
+	}
+
+	for _, section := range sections {
+		if section.missing {
+			return fmt.Errorf("config: %s section is required", section.name)
+		}
+	}
+
+	// Download urls could be forged with empty secret
+	if c.File.UrlSecret == "" {
+		return errors.New("config: file.url_secret must not be empty")
+	}
+
+	// Zero windows forbid actions, zero intervals break tickers and background loops
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"chat.delete_window", c.Chat.DeleteWindow},
+		{"chat.edit_window", c.Chat.EditWindow},
+		{"chat.scheduled_dispatch_interval", c.Chat.ScheduledDispatchInterval},
+		{"chat.scheduled_lease_time", c.Chat.ScheduledLeaseTime},
+		{"chat.reaper_interval", c.Chat.ReaperInterval},
+		{"chat.receipts_flush_interval", c.Chat.ReceiptsFlushInterval},
+		{"call.ringing_timeout", c.Call.RingingTimeout},
+		{"call.sweep_interval", c.Call.SweepInterval},
+	}
+
+	for _, duration := range durations {
+		if duration.value <= 0 {
+			return fmt.Errorf("config: %s must be positive, got %s", duration.name, duration.value)
+		}
+	}
+
+	sizes := []struct {
+		name  string
+		value int64
+	}{
+		{"chat.mentions_limit", c.Chat.MentionsLimit},
+		{"chat.reaper_batch_size", c.Chat.ReaperBatchSize},
+		{"chat.receipts_batch_size", int64(c.Chat.ReceiptsBatchSize)},
+		{"file.processing_workers", int64(c.File.ProcessingWorkers)},
+		{"file.processing_queue_size", int64(c.File.ProcessingQueueSize)},
+	}
+
+	for _, size := range sizes {
+		if size.value <= 0 {
+			return fmt.Errorf("config: %s must be positive, got %d", size.name, size.value)
+		}
+	}
+
+	return nil
+}
+
 func getConfigPath() string {
 	envPath := os.Getenv(environmentVariable)
 	if envPath != "" {
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestConfig() Config {
+	return Config{
+		Chat: &Chat{
+			DeleteWindow:              time.Hour,
+			EditWindow:                time.Hour,
+			MentionsLimit:             50,
+			ScheduledDispatchInterval: time.Second,
+			ScheduledLeaseTime:        time.Minute,
+			ReaperInterval:            time.Second,
+			ReaperBatchSize:           100,
+			ReceiptsBatchSize:         100,
+			ReceiptsFlushInterval:     time.Second,
+		},
+		Call: &Call{
+			RingingTimeout: time.Minute,
+			SweepInterval:  time.Second,
+		},
+		File: &File{
+			UrlSecret:           "secret",
+			ProcessingWorkers:   1,
+			ProcessingQueueSize: 10,
+		},
+		Storage: &Storage{
+			Type: "local",
+		},
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(config *Config)
+		err    string
+	}{
+		{"valid", func(*Config) {}, ""},
+		{"missing call", func(config *Config) { config.Call = nil }, "config: call section is required"},
+		{"missing chat", func(config *Config) { config.Chat = nil }, "config: chat section is required"},
+		{"missing storage", func(config *Config) { config.Storage = nil }, "config: storage section is required"},
+		{"empty url secret", func(config *Config) { config.File.UrlSecret = "" }, "config: file.url_secret must not be empty"},
+		{
+			"zero sweep interval",
+			func(config *Config) { config.Call.SweepInterval = 0 },
+			"config: call.sweep_interval must be positive, got 0s",
+		},
+		{
+			"negative receipts flush interval",
+			func(config *Config) { config.Chat.ReceiptsFlushInterval = -time.Second },
+			"config: chat.receipts_flush_interval must be positive, got -1s",
+		},
+		{
+			"zero scheduled lease time",
+			func(config *Config) { config.Chat.ScheduledLeaseTime = 0 },
+			"config: chat.scheduled_lease_time must be positive, got 0s",
+		},
+		{
+			"zero reaper batch size",
+			func(config *Config) { config.Chat.ReaperBatchSize = 0 },
+			"config: chat.reaper_batch_size must be positive, got 0",
+		},
+		{
+			"zero mentions limit",
+			func(config *Config) { config.Chat.MentionsLimit = 0 },
+			"config: chat.mentions_limit must be positive, got 0",
+		},
+		{
+			"zero processing workers",
+			func(config *Config) { config.File.ProcessingWorkers = 0 },
+			"config: file.processing_workers must be positive, got 0",
+		},
+		{
+			"zero processing queue size",
+			func(config *Config) { config.File.ProcessingQueueSize = 0 },
+			"config: file.processing_queue_size must be positive, got 0",
+		},
+		{
+			"zero edit window",
+			func(config *Config) { config.Chat.EditWindow = 0 },
+			"config: chat.edit_window must be positive, got 0s",
+		},
+		{
+			"zero delete window",
+			func(config *Config) { config.Chat.DeleteWindow = 0 },
+			"config: chat.delete_window must be positive, got 0s",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := newTestConfig()
+			test.modify(&config)
+
+			err := config.validate()
+			if test.err == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -2,6 +2,9 @@ package calldomain
 
 import (
 	"encoding/json"
+	"time"
+
+	"github.com/undefined7887/harmony-backend/internal/domain"
 )
 
 type CallDTO struct {
@@ -9,14 +12,51 @@ type CallDTO struct {
 	UserID string `json:"user_id"`
 	PeerID string `json:"peer_id"`
 	Status string `json:"status"`
+
+	// Relative to current user, filled by MapUserCallDTO
+	Direction string `json:"direction,omitempty"`
+
+	// Empty for calls in progress
+	Outcome string `json:"outcome,omitempty"`
+
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	EndedAt    *time.Time `json:"ended_at,omitempty"`
+	EndedBy    string     `json:"ended_by,omitempty"`
+
+	// Durations in seconds, calls in progress are measured up to current time
+	RingDuration int64 `json:"ring_duration"`
+	Duration     int64 `json:"duration"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 func MapCallDTO(call Call) CallDTO {
+	now := time.Now()
+
 	return CallDTO{
-		ID:     call.ID,
-		UserID: call.UserID,
-		PeerID: call.PeerID,
-		Status: call.Status,
+		ID:           call.ID,
+		UserID:       call.UserID,
+		PeerID:       call.PeerID,
+		Status:       call.Status,
+		Outcome:      call.Outcome(),
+		AcceptedAt:   call.AcceptedAt,
+		EndedAt:      call.EndedAt,
+		EndedBy:      call.EndedBy,
+		RingDuration: int64(call.RingDuration(now) / time.Second),
+		Duration:     int64(call.Duration(now) / time.Second),
+		CreatedAt:    call.CreatedAt,
+		UpdatedAt:    call.UpdatedAt,
+	}
+}
+
+// MapUserCallDTO returns mapper, which fills user specific fields for provided user
+func MapUserCallDTO(userID string) func(call Call) CallDTO {
+	return func(call Call) CallDTO {
+		dto := MapCallDTO(call)
+		dto.Direction = call.Direction(userID)
+
+		return dto
 	}
 }
 
@@ -36,6 +76,23 @@ type NewCallNotification struct {
 
 // --
 
+type ListCallsRequestQuery struct {
+	// Only calls with provided user, unknown user is rejected with not found error
+	PeerID string `form:"peer_id" binding:"omitempty,id"`
+
+	domain.PaginationQuery
+}
+
+type ListCallsResponse struct {
+	Items []CallDTO `json:"items"`
+
+	// Cursors for "before" and "after" query parameters
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// --
+
 type UpdateCallRequestBody struct {
 	Status string `json:"status" binding:"oneof=accepted declined finished"`
 }
@@ -26,3 +26,21 @@ func ErrCallAlreadyExists() *domain.Error {
 		Name: "ERR_CALL(S)_ALREADY_EXISTS",
 	}
 }
+
+func ErrCallTransitionInvalid() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusConflict,
+
+		Code: ErrIndex + 3,
+		Name: "ERR_CALL_TRANSITION_INVALID",
+	}
+}
+
+func ErrCallTransitionForbidden() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusForbidden,
+
+		Code: ErrIndex + 4,
+		Name: "ERR_CALL_TRANSITION_FORBIDDEN",
+	}
+}
@@ -9,6 +9,20 @@ const (
 	StatusAccepted = "accepted"
 	StatusDeclined = "declined"
 	StatusFinished = "finished"
+
+	// Set automatically, if peer didn't answer during ringing timeout
+	StatusMissed = "missed"
+)
+
+const (
+	DirectionIncoming = "incoming"
+	DirectionOutgoing = "outgoing"
+)
+
+const (
+	OutcomeMissed    = "missed"
+	OutcomeDeclined  = "declined"
+	OutcomeCompleted = "completed"
 )
 
 type Call struct {
@@ -19,6 +33,102 @@ type Call struct {
 
 	Status string `bson:"status"`
 
+	// Time, when peer accepted call
+	AcceptedAt *time.Time `bson:"accepted_at,omitempty"`
+
+	// Time, when call was declined or finished, and user, who did it.
+	// User is empty, if call was ended automatically
+	EndedAt *time.Time `bson:"ended_at,omitempty"`
+	EndedBy string     `bson:"ended_by,omitempty"`
+
 	CreatedAt time.Time `bson:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at"`
 }
+
+// Direction returns direction of call for provided participant
+func (c Call) Direction(userID string) string {
+	if c.UserID == userID {
+		return DirectionOutgoing
+	}
+
+	return DirectionIncoming
+}
+
+// Outcome returns result of ended call, empty for calls in progress
+func (c Call) Outcome() string {
+	switch c.Status {
+	case StatusDeclined:
+		return OutcomeDeclined
+
+	case StatusMissed:
+		return OutcomeMissed
+
+	case StatusFinished:
+		// Caller hung up before peer answered
+		if c.AcceptedAt == nil {
+			return OutcomeMissed
+		}
+
+		return OutcomeCompleted
+	}
+
+	return ""
+}
+
+// RingDuration returns time between call creation and its acceptance or end
+func (c Call) RingDuration(now time.Time) time.Duration {
+	switch {
+	case c.AcceptedAt != nil:
+		return c.AcceptedAt.Sub(c.CreatedAt)
+
+	case c.EndedAt != nil:
+		return c.EndedAt.Sub(c.CreatedAt)
+	}
+
+	return now.Sub(c.CreatedAt)
+}
+
+// Duration returns conversation time, zero for calls, which were not accepted
+func (c Call) Duration(now time.Time) time.Duration {
+	switch {
+	case c.AcceptedAt == nil:
+		return 0
+
+	case c.EndedAt != nil:
+		return c.EndedAt.Sub(*c.AcceptedAt)
+	}
+
+	return now.Sub(*c.AcceptedAt)
+}
+
+// StatusUpdate is a transition of call to new status, empty fields are not updated
+type StatusUpdate struct {
+	Status string `bson:"status"`
+
+	AcceptedAt *time.Time `bson:"accepted_at,omitempty"`
+
+	EndedAt *time.Time `bson:"ended_at,omitempty"`
+	EndedBy string     `bson:"ended_by,omitempty"`
+
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// NewStatusUpdate returns transition to provided status, made by user at provided time.
+// User is empty for automatic transitions
+func NewStatusUpdate(userID, status string, now time.Time) StatusUpdate {
+	update := StatusUpdate{
+		Status:    status,
+		UpdatedAt: now,
+	}
+
+	switch status {
+	case StatusAccepted:
+		update.AcceptedAt = &now
+
+	case StatusDeclined, StatusFinished, StatusMissed:
+		update.EndedAt = &now
+		update.EndedBy = userID
+	}
+
+	return update
+}
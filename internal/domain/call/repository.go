@@ -2,13 +2,24 @@ package calldomain
 
 import (
 	"context"
+	"time"
+
+	"github.com/undefined7887/harmony-backend/internal/domain"
 )
 
 type Repository interface {
 	Create(ctx context.Context, call *Call) (bool, error)
 
+	Get(ctx context.Context, id string) (Call, error)
 	Read(ctx context.Context, id, status string) (Call, error)
 	ReadLast(ctx context.Context, userID, status string) (Call, error)
 
-	UpdateStatus(ctx context.Context, id, peerID string, previousStatuses []string, newStatus string) (Call, error)
+	// ListByStatus returns calls with provided status, created before provided time
+	ListByStatus(ctx context.Context, status string, createdBefore time.Time) ([]Call, error)
+
+	// List returns calls of user, optionally only with provided peer, sorted from newest to oldest
+	List(ctx context.Context, userID, peerID string, pagination domain.Pagination) (domain.Page[Call], error)
+
+	// UpdateStatus applies update, only if call still has previous status
+	UpdateStatus(ctx context.Context, id, previousStatus string, update StatusUpdate) (Call, error)
 }
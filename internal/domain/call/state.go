@@ -0,0 +1,73 @@
+package calldomain
+
+const (
+	RoleCaller = "caller"
+	RoleCallee = "callee"
+
+	// Automatic transitions, made by background sweeper
+	RoleSystem = "system"
+)
+
+// Statuses are all call statuses, declined, finished and missed ones are terminal
+var Statuses = []string{
+	StatusRequest,
+	StatusAccepted,
+	StatusDeclined,
+	StatusFinished,
+	StatusMissed,
+}
+
+// Roles are all roles, which can change call status
+var Roles = []string{
+	RoleCaller,
+	RoleCallee,
+	RoleSystem,
+}
+
+// transitions maps current status to allowed next statuses and roles, which can make transition
+var transitions = map[string]map[string][]string{
+	StatusRequest: {
+		// Only peer can answer call
+		StatusAccepted: {RoleCallee},
+
+		// Caller declines to cancel request, callee - to reject it
+		StatusDeclined: {RoleCaller, RoleCallee},
+		StatusFinished: {RoleCaller, RoleCallee},
+
+		// Peer didn't answer during ringing timeout
+		StatusMissed: {RoleSystem},
+	},
+	StatusAccepted: {
+		// System finishes calls with offline participants
+		StatusFinished: {RoleCaller, RoleCallee, RoleSystem},
+	},
+}
+
+// Role returns role of user in call, empty if user is not a participant
+func (c Call) Role(userID string) string {
+	switch userID {
+	case c.UserID:
+		return RoleCaller
+
+	case c.PeerID:
+		return RoleCallee
+	}
+
+	return ""
+}
+
+// CheckTransition returns error, if call can't be moved from one status to another by provided role
+func CheckTransition(from, to, role string) error {
+	roles, ok := transitions[from][to]
+	if !ok {
+		return ErrCallTransitionInvalid()
+	}
+
+	for _, item := range roles {
+		if item == role {
+			return nil
+		}
+	}
+
+	return ErrCallTransitionForbidden()
+}
@@ -0,0 +1,142 @@
+package calldomain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/undefined7887/harmony-backend/internal/domain"
+)
+
+func TestCheckTransition(t *testing.T) {
+	// All allowed transitions, any other combination must be rejected
+	allowed := []struct {
+		from string
+		to   string
+		role string
+	}{
+		{StatusRequest, StatusAccepted, RoleCallee},
+		{StatusRequest, StatusDeclined, RoleCaller},
+		{StatusRequest, StatusDeclined, RoleCallee},
+		{StatusRequest, StatusFinished, RoleCaller},
+		{StatusRequest, StatusFinished, RoleCallee},
+		{StatusRequest, StatusMissed, RoleSystem},
+		{StatusAccepted, StatusFinished, RoleCaller},
+		{StatusAccepted, StatusFinished, RoleCallee},
+		{StatusAccepted, StatusFinished, RoleSystem},
+	}
+
+	isAllowed := func(from, to, role string) bool {
+		for _, item := range allowed {
+			if item.from == from && item.to == to && item.role == role {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	// Transition exists, but isn't available for some roles
+	exists := func(from, to string) bool {
+		for _, item := range allowed {
+			if item.from == from && item.to == to {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	// Empty role is used for users, who are not participants
+	roles := append([]string{""}, Roles...)
+
+	for _, from := range Statuses {
+		for _, to := range Statuses {
+			for _, role := range roles {
+				err := CheckTransition(from, to, role)
+
+				switch {
+				case isAllowed(from, to, role):
+					assert.NoError(t, err, "%s -> %s by %q", from, to, role)
+
+				case exists(from, to):
+					assert.True(t,
+						domain.IsError(err, ErrCallTransitionForbidden()),
+						"%s -> %s by %q: %v", from, to, role, err,
+					)
+
+				default:
+					assert.True(t,
+						domain.IsError(err, ErrCallTransitionInvalid()),
+						"%s -> %s by %q: %v", from, to, role, err,
+					)
+				}
+			}
+		}
+	}
+}
+
+func TestCheckTransitionCases(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		role string
+		err  *domain.Error
+	}{
+		{"callee accepts", StatusRequest, StatusAccepted, RoleCallee, nil},
+		{"caller can't accept own call", StatusRequest, StatusAccepted, RoleCaller, ErrCallTransitionForbidden()},
+		{"system can't accept", StatusRequest, StatusAccepted, RoleSystem, ErrCallTransitionForbidden()},
+		{"caller cancels request", StatusRequest, StatusDeclined, RoleCaller, nil},
+		{"callee declines request", StatusRequest, StatusDeclined, RoleCallee, nil},
+		{"caller hangs up before answer", StatusRequest, StatusFinished, RoleCaller, nil},
+		{"system marks missed", StatusRequest, StatusMissed, RoleSystem, nil},
+		{"callee can't mark missed", StatusRequest, StatusMissed, RoleCallee, ErrCallTransitionForbidden()},
+		{"caller finishes call", StatusAccepted, StatusFinished, RoleCaller, nil},
+		{"callee finishes call", StatusAccepted, StatusFinished, RoleCallee, nil},
+		{"system finishes call", StatusAccepted, StatusFinished, RoleSystem, nil},
+		{"non-participant can't finish call", StatusAccepted, StatusFinished, "", ErrCallTransitionForbidden()},
+		{"accepted call can't be declined", StatusAccepted, StatusDeclined, RoleCallee, ErrCallTransitionInvalid()},
+		{"accepted call can't be missed", StatusAccepted, StatusMissed, RoleSystem, ErrCallTransitionInvalid()},
+		{"call can't be accepted twice", StatusAccepted, StatusAccepted, RoleCallee, ErrCallTransitionInvalid()},
+		{"declined call is terminal", StatusDeclined, StatusAccepted, RoleCallee, ErrCallTransitionInvalid()},
+		{"finished call is terminal", StatusFinished, StatusFinished, RoleCaller, ErrCallTransitionInvalid()},
+		{"missed call is terminal", StatusMissed, StatusAccepted, RoleCallee, ErrCallTransitionInvalid()},
+		{"call can't return to request", StatusAccepted, StatusRequest, RoleSystem, ErrCallTransitionInvalid()},
+		{"unknown status", "unknown", StatusFinished, RoleCaller, ErrCallTransitionInvalid()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckTransition(test.from, test.to, test.role)
+
+			if test.err == nil {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.True(t, domain.IsError(err, test.err), "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestCallRole(t *testing.T) {
+	call := Call{
+		UserID: "caller",
+		PeerID: "callee",
+	}
+
+	tests := []struct {
+		userID string
+		role   string
+	}{
+		{"caller", RoleCaller},
+		{"callee", RoleCallee},
+		{"stranger", ""},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.role, call.Role(test.userID), test.userID)
+	}
+}
@@ -10,14 +10,46 @@ func ChannelMessageNew(userID string) string {
 	return fmt.Sprintf("%s:message/new#%s", ChannelNamespace, userID)
 }
 
+func ChannelMentionNew(userID string) string {
+	return fmt.Sprintf("%s:mention/new#%s", ChannelNamespace, userID)
+}
+
 func ChannelMessageUpdates(userID string) string {
 	return fmt.Sprintf("%s:message/updates#%s", ChannelNamespace, userID)
 }
 
+func ChannelMessageDeletes(userID string) string {
+	return fmt.Sprintf("%s:message/deletes#%s", ChannelNamespace, userID)
+}
+
 func ChannelReadUpdates(userID string) string {
 	return fmt.Sprintf("%s:read/updates#%s", ChannelNamespace, userID)
 }
 
+func ChannelDeliveryUpdates(userID string) string {
+	return fmt.Sprintf("%s:delivery/updates#%s", ChannelNamespace, userID)
+}
+
 func ChannelTypingUpdates(userID string) string {
 	return fmt.Sprintf("%s:typing/updates#%s", ChannelNamespace, userID)
 }
+
+func ChannelGroupUpdates(userID string) string {
+	return fmt.Sprintf("%s:group/updates#%s", ChannelNamespace, userID)
+}
+
+func ChannelPinUpdates(userID string) string {
+	return fmt.Sprintf("%s:pin/updates#%s", ChannelNamespace, userID)
+}
+
+func ChannelSettingsUpdates(userID string) string {
+	return fmt.Sprintf("%s:settings/updates#%s", ChannelNamespace, userID)
+}
+
+func ChannelDraftUpdates(userID string) string {
+	return fmt.Sprintf("%s:draft/updates#%s", ChannelNamespace, userID)
+}
+
+func ChannelTTLUpdates(userID string) string {
+	return fmt.Sprintf("%s:ttl/updates#%s", ChannelNamespace, userID)
+}
@@ -1,54 +1,554 @@
 package chatdomain
 
 import (
+	"sort"
 	"time"
 
+	"github.com/samber/lo"
+
 	"github.com/undefined7887/harmony-backend/internal/domain"
+	filedomain "github.com/undefined7887/harmony-backend/internal/domain/file"
+	"github.com/undefined7887/harmony-backend/internal/util"
 )
 
 type ChatDTO struct {
-	ID          string     `json:"id"`
-	Type        string     `json:"type"`
-	Name        string     `json:"name,omitempty"`
-	Message     MessageDTO `json:"message"`
-	UnreadCount int64      `json:"unread_count"`
+	ID                  string      `json:"id"`
+	Type                string      `json:"type"`
+	Name                string      `json:"name,omitempty"`
+	Message             MessageDTO  `json:"message"`
+	PinnedMessage       *MessageDTO `json:"pinned_message,omitempty"`
+	UnreadCount         int64       `json:"unread_count"`
+	UnreadMentionsCount int64       `json:"unread_mentions_count"`
+	Draft               *DraftDTO   `json:"draft,omitempty"`
+
+	ChatSettingsDTO
 }
 
 func MapChatDTO(chat Chat) ChatDTO {
-	return ChatDTO{
-		ID:          chat.ID,
-		Type:        chat.Type,
-		Name:        chat.Name,
-		Message:     MapMessageDTO(chat.Message),
-		UnreadCount: chat.UnreadCount,
+	dto := ChatDTO{
+		ID:                  chat.ID,
+		Type:                chat.Type,
+		Name:                chat.Name,
+		Message:             MapMessageDTO(chat.Message),
+		UnreadCount:         chat.UnreadCount,
+		UnreadMentionsCount: chat.UnreadMentionsCount,
+		ChatSettingsDTO:     MapChatSettingsDTO(chat.ChatSettings),
+	}
+
+	if chat.PinnedMessage != nil {
+		pinnedMessage := MapMessageDTO(*chat.PinnedMessage)
+		dto.PinnedMessage = &pinnedMessage
+	}
+
+	if chat.Draft != nil {
+		draft := MapDraftDTO(*chat.Draft)
+		dto.Draft = &draft
+	}
+
+	return dto
+}
+
+// MapUserChatDTO returns mapper, which fills user specific fields for provided user
+func MapUserChatDTO(userID string, summaries []ReadSummary) func(chat Chat) ChatDTO {
+	return func(chat Chat) ChatDTO {
+		dto := MapChatDTO(chat)
+		dto.Message = MapUserMessageDTO(userID, summaries)(chat.Message)
+
+		if chat.PinnedMessage != nil {
+			pinnedMessage := MapUserMessageDTO(userID, summaries)(*chat.PinnedMessage)
+			dto.PinnedMessage = &pinnedMessage
+		}
+
+		return dto
+	}
+}
+
+type DraftDTO struct {
+	Text      string    `json:"text"`
+	ReplyToID string    `json:"reply_to_id,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func MapDraftDTO(draft Draft) DraftDTO {
+	return DraftDTO{
+		Text:      draft.Text,
+		ReplyToID: draft.ReplyToID,
+		UpdatedAt: draft.UpdatedAt,
+	}
+}
+
+type ChatTTLDTO struct {
+	// Lifetime of new messages in seconds, zero if messages don't expire
+	TTL int64 `json:"ttl"`
+
+	// User, who changed lifetime
+	UserID    string     `json:"user_id,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func MapChatTTLDTO(ttl ChatTTL) ChatTTLDTO {
+	dto := ChatTTLDTO{
+		TTL:    int64(ttl.TTL / time.Second),
+		UserID: ttl.UserID,
+	}
+
+	if !ttl.UpdatedAt.IsZero() {
+		dto.UpdatedAt = &ttl.UpdatedAt
+	}
+
+	return dto
+}
+
+type ChatSettingsDTO struct {
+	MutedUntil *time.Time `json:"muted_until,omitempty"`
+	Archived   bool       `json:"archived"`
+	PinOrder   int64      `json:"pin_order"`
+}
+
+func MapChatSettingsDTO(settings ChatSettings) ChatSettingsDTO {
+	return ChatSettingsDTO{
+		MutedUntil: settings.MutedUntil,
+		Archived:   settings.Archived,
+		PinOrder:   settings.PinOrder,
+	}
+}
+
+type FolderDTO struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Chats     []FolderChatDTO `json:"chats"`
+	PeerTypes []string        `json:"peer_types"`
+	Unread    bool            `json:"unread"`
+	UserIDs   []string        `json:"user_ids"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func MapFolderDTO(folder Folder) FolderDTO {
+	return FolderDTO{
+		ID:        folder.ID,
+		Name:      folder.Name,
+		Chats:     util.Map(folder.Chats, MapFolderChatDTO),
+		PeerTypes: folder.PeerTypes,
+		Unread:    folder.Unread,
+		UserIDs:   folder.UserIDs,
+		CreatedAt: folder.CreatedAt,
+		UpdatedAt: folder.UpdatedAt,
+	}
+}
+
+type FolderChatDTO struct {
+	PeerID   string `json:"peer_id" binding:"id"`
+	PeerType string `json:"peer_type" binding:"oneof=user group"`
+}
+
+func MapFolderChatDTO(chat FolderChat) FolderChatDTO {
+	return FolderChatDTO{
+		PeerID:   chat.PeerID,
+		PeerType: chat.PeerType,
+	}
+}
+
+type FolderUnreadDTO struct {
+	FolderID            string `json:"folder_id"`
+	UnreadChatsCount    int64  `json:"unread_chats_count"`
+	UnreadCount         int64  `json:"unread_count"`
+	UnreadMentionsCount int64  `json:"unread_mentions_count"`
+}
+
+func MapFolderUnreadDTO(folder Folder, unread FolderUnread) FolderUnreadDTO {
+	return FolderUnreadDTO{
+		FolderID:            folder.ID,
+		UnreadChatsCount:    unread.UnreadChatsCount,
+		UnreadCount:         unread.UnreadCount,
+		UnreadMentionsCount: unread.UnreadMentionsCount,
 	}
 }
 
 type MessageDTO struct {
-	ID          string    `json:"id"`
-	UserID      string    `json:"user_id"`
-	PeerID      string    `json:"peer_id"`
-	PeerType    string    `json:"peer_type"`
-	Text        string    `json:"text"`
-	Edited      bool      `json:"edited"`
-	Attachments []string  `json:"attachments,omitempty"`
-	ReadUserIDs []string  `json:"read_user_ids"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID             string             `json:"id"`
+	UserID         string             `json:"user_id"`
+	PeerID         string             `json:"peer_id"`
+	PeerType       string             `json:"peer_type"`
+	Kind           string             `json:"kind"`
+	Text           string             `json:"text"`
+	Edited         bool               `json:"edited"`
+	EditedAt       *time.Time         `json:"edited_at,omitempty"`
+	MentionUserIDs []string           `json:"mention_user_ids,omitempty"`
+	Attachments    []AttachmentDTO    `json:"attachments,omitempty"`
+	ReplyTo        *MessagePreviewDTO `json:"reply_to,omitempty"`
+	ForwardedFrom  *MessageForwardDTO `json:"forwarded_from,omitempty"`
+	ThreadRootID   string             `json:"thread_root_id,omitempty"`
+	Thread         *ThreadDTO         `json:"thread,omitempty"`
+	Reactions      []ReactionDTO      `json:"reactions,omitempty"`
+	Deleted        bool               `json:"deleted"`
+	DeliveredTo    []ReceiptDTO       `json:"delivered_to,omitempty"`
+
+	// Read is set for messages of user, which are read by any other participant.
+	// Receipts of readers are listed only for a single message
+	Read   bool         `json:"read"`
+	ReadBy []ReceiptDTO `json:"read_by,omitempty"`
+
+	Poll      *PollDTO   `json:"poll,omitempty"`
+	ExpireAt  *time.Time `json:"expire_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
 }
 
 func MapMessageDTO(message Message) MessageDTO {
 	return MessageDTO{
-		ID:          message.ID,
-		UserID:      message.UserID,
-		PeerID:      message.PeerID,
-		PeerType:    message.PeerType,
-		Text:        message.Text,
-		Edited:      message.Edited,
-		Attachments: message.Attachments,
-		ReadUserIDs: message.ReadUserIDs,
-		CreatedAt:   message.CreatedAt,
-		UpdatedAt:   message.UpdatedAt,
+		ID:             message.ID,
+		UserID:         message.UserID,
+		PeerID:         message.PeerID,
+		PeerType:       message.PeerType,
+		Kind:           lo.Ternary(message.Kind != "", message.Kind, MessageKindText),
+		Text:           message.Text,
+		Edited:         message.Edited,
+		EditedAt:       message.EditedAt,
+		MentionUserIDs: message.MentionUserIDs,
+		Attachments:    util.Map(message.Attachments, mapAttachmentDTO),
+		ReplyTo:        mapMessagePreviewDTO(message.ReplyTo),
+		ForwardedFrom:  mapMessageForwardDTO(message.ForwardedFrom),
+		ThreadRootID:   message.ThreadRootID,
+		Thread:         mapThreadDTO(message),
+		Reactions:      mapReactionDTOs(message.Reactions, ""),
+		Deleted:        message.Deleted,
+		DeliveredTo:    util.Map(message.Deliveries, MapReceiptDTO),
+		Poll:           mapPollDTO(message.Poll),
+		ExpireAt:       message.ExpireAt,
+		CreatedAt:      message.CreatedAt,
+		UpdatedAt:      message.UpdatedAt,
+	}
+}
+
+// MapUserMessageDTO returns mapper, which fills user specific fields for provided user.
+// Messages of user are marked read according to provided read summaries of other participants
+func MapUserMessageDTO(userID string, summaries []ReadSummary) func(message Message) MessageDTO {
+	return func(message Message) MessageDTO {
+		dto := MapMessageDTO(message)
+		dto.Reactions = mapReactionDTOs(message.Reactions, userID)
+		dto.Read = message.UserID == userID && message.ReadIn(summaries)
+
+		return dto
+	}
+}
+
+// MapUserMessageReadsDTO returns mapper like MapUserMessageDTO,
+// which also lists receipts of participants, who read message according to provided read states
+func MapUserMessageReadsDTO(userID string, states []ReadState) func(message Message) MessageDTO {
+	return func(message Message) MessageDTO {
+		reads := message.Reads(states)
+
+		dto := MapUserMessageDTO(userID, nil)(message)
+		dto.Read = message.UserID == userID && len(reads) > 0
+		dto.ReadBy = util.Map(reads, MapReceiptDTO)
+		dto.DeliveredTo = util.Map(DeliveredReceipts(message, reads), MapReceiptDTO)
+
+		return dto
+	}
+}
+
+// DeliveredReceipts returns delivery receipts of message, read messages are delivered too
+func DeliveredReceipts(message Message, reads []Receipt) []Receipt {
+	receipts := append(append([]Receipt{}, message.Deliveries...), reads...)
+
+	return lo.UniqBy(receipts, func(receipt Receipt) string {
+		return receipt.UserID
+	})
+}
+
+// ReceiptDTO is a delivery or read receipt of message.
+// For read receipts CreatedAt is time of the last read in chat, see Message.Reads
+type ReceiptDTO struct {
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func MapReceiptDTO(receipt Receipt) ReceiptDTO {
+	return ReceiptDTO{
+		UserID:    receipt.UserID,
+		CreatedAt: receipt.CreatedAt,
+	}
+}
+
+type AttachmentDTO struct {
+	ID         string                    `json:"id"`
+	Name       string                    `json:"name"`
+	MimeType   string                    `json:"mime_type"`
+	Size       int64                     `json:"size"`
+	Width      int                       `json:"width,omitempty"`
+	Height     int                       `json:"height,omitempty"`
+	Thumbnails []filedomain.ThumbnailDTO `json:"thumbnails,omitempty"`
+	Processing bool                      `json:"processing"`
+}
+
+func mapAttachmentDTO(attachment Attachment) AttachmentDTO {
+	return AttachmentDTO{
+		ID:         attachment.ID,
+		Name:       attachment.Name,
+		MimeType:   attachment.MimeType,
+		Size:       attachment.Size,
+		Width:      attachment.Width,
+		Height:     attachment.Height,
+		Thumbnails: util.Map(attachment.Thumbnails, filedomain.MapThumbnailDTO),
+		Processing: attachment.Processing,
+	}
+}
+
+type MessagePreviewDTO struct {
+	ID      string `json:"id"`
+	UserID  string `json:"user_id"`
+	Text    string `json:"text"`
+	Deleted bool   `json:"deleted"`
+}
+
+func mapMessagePreviewDTO(preview *MessagePreview) *MessagePreviewDTO {
+	if preview == nil {
+		return nil
+	}
+
+	return &MessagePreviewDTO{
+		ID:      preview.ID,
+		UserID:  preview.UserID,
+		Text:    preview.Text,
+		Deleted: preview.Deleted,
+	}
+}
+
+type MessageForwardDTO struct {
+	MessageID string `json:"message_id"`
+	UserID    string `json:"user_id"`
+	PeerType  string `json:"peer_type"`
+	PeerID    string `json:"peer_id,omitempty"`
+}
+
+func mapMessageForwardDTO(forward *MessageForward) *MessageForwardDTO {
+	if forward == nil {
+		return nil
+	}
+
+	return &MessageForwardDTO{
+		MessageID: forward.MessageID,
+		UserID:    forward.UserID,
+		PeerType:  forward.PeerType,
+		PeerID:    forward.PeerID,
+	}
+}
+
+type ThreadDTO struct {
+	ReplyCount  int64     `json:"reply_count"`
+	LastReplyAt time.Time `json:"last_reply_at"`
+}
+
+func mapThreadDTO(message Message) *ThreadDTO {
+	if message.ThreadLastReplyAt == nil {
+		return nil
+	}
+
+	return &ThreadDTO{
+		ReplyCount:  message.ThreadReplyCount,
+		LastReplyAt: *message.ThreadLastReplyAt,
+	}
+}
+
+type ReactionDTO struct {
+	Emoji string `json:"emoji"`
+	Count int64  `json:"count"`
+
+	// Current user reacted with this emoji
+	Reacted bool `json:"reacted"`
+}
+
+func mapReactionDTOs(reactions map[string][]string, userID string) []ReactionDTO {
+	result := make([]ReactionDTO, 0, len(reactions))
+
+	for emoji, userIDs := range reactions {
+		// Reactions with all users removed are kept in database
+		if len(userIDs) == 0 {
+			continue
+		}
+
+		result = append(result, ReactionDTO{
+			Emoji:   emoji,
+			Count:   int64(len(userIDs)),
+			Reacted: userID != "" && lo.Contains(userIDs, userID),
+		})
+	}
+
+	// Most popular reactions go first
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+
+		return result[i].Emoji < result[j].Emoji
+	})
+
+	return result
+}
+
+type PollDTO struct {
+	Question       string          `json:"question"`
+	Options        []PollOptionDTO `json:"options"`
+	MultipleChoice bool            `json:"multiple_choice"`
+	Anonymous      bool            `json:"anonymous"`
+	CloseAt        *time.Time      `json:"close_at,omitempty"`
+	VoterCount     int64           `json:"voter_count"`
+}
+
+func mapPollDTO(poll *Poll) *PollDTO {
+	if poll == nil {
+		return nil
+	}
+
+	return &PollDTO{
+		Question: poll.Question,
+		Options: util.Map(poll.Options, func(option PollOption) PollOptionDTO {
+			return PollOptionDTO{
+				ID:        option.ID,
+				Text:      option.Text,
+				VoteCount: option.VoteCount,
+			}
+		}),
+		MultipleChoice: poll.MultipleChoice,
+		Anonymous:      poll.Anonymous,
+		CloseAt:        poll.CloseAt,
+		VoterCount:     poll.VoterCount,
+	}
+}
+
+type PollOptionDTO struct {
+	ID        int    `json:"id"`
+	Text      string `json:"text"`
+	VoteCount int64  `json:"vote_count"`
+}
+
+type PollVoteDTO struct {
+	UserID    string    `json:"user_id"`
+	OptionIDs []int     `json:"option_ids"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func MapPollVoteDTO(vote PollVote) PollVoteDTO {
+	return PollVoteDTO{
+		UserID:    vote.UserID,
+		OptionIDs: vote.OptionIDs,
+		CreatedAt: vote.CreatedAt,
+	}
+}
+
+type MessageSearchResultDTO struct {
+	Message MessageDTO `json:"message"`
+	Snippet SnippetDTO `json:"snippet"`
+}
+
+// MapUserMessageSearchResultDTO returns mapper, which fills user specific fields for provided user
+// and reads from provided read summaries of messages chats
+func MapUserMessageSearchResultDTO(
+	userID string,
+	summaries []ReadSummary,
+) func(result MessageSearchResult) MessageSearchResultDTO {
+	return func(result MessageSearchResult) MessageSearchResultDTO {
+		return MessageSearchResultDTO{
+			Message: MapUserMessageDTO(userID, summaries)(result.Message),
+			Snippet: SnippetDTO{
+				Text: result.Snippet.Text,
+				Highlights: util.Map(result.Snippet.Highlights, func(highlight Highlight) HighlightDTO {
+					return HighlightDTO{
+						Start: highlight.Start,
+						End:   highlight.End,
+					}
+				}),
+			},
+		}
+	}
+}
+
+type SnippetDTO struct {
+	Text       string         `json:"text"`
+	Highlights []HighlightDTO `json:"highlights"`
+}
+
+// HighlightDTO is a range of characters (unicode code points) in snippet, end is exclusive
+type HighlightDTO struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type PinDTO struct {
+	// User, who pinned message
+	UserID    string     `json:"user_id"`
+	Message   MessageDTO `json:"message"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type RevisionDTO struct {
+	Text       string    `json:"text"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+func MapRevisionDTO(revision Revision) RevisionDTO {
+	return RevisionDTO{
+		Text:       revision.Text,
+		CreatedAt:  revision.CreatedAt,
+		ReplacedAt: revision.ReplacedAt,
+	}
+}
+
+type GroupDTO struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func MapGroupDTO(group Group) GroupDTO {
+	return GroupDTO{
+		ID:        group.ID,
+		UserID:    group.UserID,
+		Name:      group.Name,
+		CreatedAt: group.CreatedAt,
+		UpdatedAt: group.UpdatedAt,
+	}
+}
+
+type GroupMemberDTO struct {
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func MapGroupMemberDTO(member GroupMember) GroupMemberDTO {
+	return GroupMemberDTO{
+		UserID:    member.UserID,
+		Role:      member.Role,
+		CreatedAt: member.CreatedAt,
+		UpdatedAt: member.UpdatedAt,
+	}
+}
+
+type GroupInviteDTO struct {
+	Token     string    `json:"token"`
+	GroupID   string    `json:"group_id"`
+	UserID    string    `json:"user_id"`
+	MaxUses   int64     `json:"max_uses"`
+	Uses      int64     `json:"uses"`
+	ExpireAt  time.Time `json:"expire_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func MapGroupInviteDTO(invite GroupInvite) GroupInviteDTO {
+	return GroupInviteDTO{
+		Token:     invite.ID,
+		GroupID:   invite.GroupID,
+		UserID:    invite.UserID,
+		MaxUses:   invite.MaxUses,
+		Uses:      invite.Uses,
+		ExpireAt:  invite.ExpireAt,
+		CreatedAt: invite.CreatedAt,
 	}
 }
 
@@ -57,18 +557,98 @@ type PeerParams struct {
 	PeerType string `uri:"peer_type" binding:"oneof=user group"`
 }
 
+// GroupParams is PeerParams restricted to group chats
+type GroupParams struct {
+	PeerID   string `uri:"peer_id" binding:"id"`
+	PeerType string `uri:"peer_type" binding:"eq=group"`
+}
+
 // ---
 
+type ScheduledMessageDTO struct {
+	ID            string    `json:"id"`
+	PeerID        string    `json:"peer_id"`
+	PeerType      string    `json:"peer_type"`
+	Text          string    `json:"text"`
+	AttachmentIDs []string  `json:"attachment_ids,omitempty"`
+	ReplyToID     string    `json:"reply_to_id,omitempty"`
+	ThreadRootID  string    `json:"thread_root_id,omitempty"`
+	Poll          *PollDTO  `json:"poll,omitempty"`
+	SendAt        time.Time `json:"send_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func MapScheduledMessageDTO(message ScheduledMessage) ScheduledMessageDTO {
+	return ScheduledMessageDTO{
+		ID:            message.ID,
+		PeerID:        message.PeerID,
+		PeerType:      message.PeerType,
+		Text:          message.Text,
+		AttachmentIDs: message.AttachmentIDs,
+		ReplyToID:     message.ReplyToID,
+		ThreadRootID:  message.ThreadRootID,
+		Poll:          mapPollDTO(message.Poll),
+		SendAt:        message.SendAt,
+		CreatedAt:     message.CreatedAt,
+		UpdatedAt:     message.UpdatedAt,
+	}
+}
+
 type CreateMessageRequestBody struct {
-	Text string `json:"text" binding:"min=1,max=1000"`
+	// Text can be empty only for messages with attachments and polls
+	Text string `json:"text" binding:"required_without_all=AttachmentIDs Poll,excluded_with=Poll,max=1000"`
+
+	// Files, uploaded by sender
+	AttachmentIDs []string `json:"attachment_ids" binding:"excluded_with=Poll,max=10,dive,id"`
+
+	// Message is sent as poll, if presented
+	Poll *CreatePollRequestBody `json:"poll"`
+
+	// Quoted message from the same chat
+	ReplyToID string `json:"reply_to_id" binding:"omitempty,id"`
+
+	// Root message of thread from the same chat
+	ThreadRootID string `json:"thread_root_id" binding:"omitempty,id"`
+
+	// Message is scheduled for sending at this time, if presented
+	SendAt *time.Time `json:"send_at"`
+}
+
+type CreatePollRequestBody struct {
+	Question string   `json:"question" binding:"min=1,max=300"`
+	Options  []string `json:"options" binding:"min=2,max=10,dive,min=1,max=100"`
+
+	MultipleChoice bool `json:"multiple_choice"`
+	Anonymous      bool `json:"anonymous"`
+
+	// Poll is open until it's deleted, if empty
+	CloseAt *time.Time `json:"close_at"`
+}
+
+// NewPoll returns poll from request body, nil body is mapped to nil poll
+func (b *CreatePollRequestBody) NewPoll() *Poll {
+	if b == nil {
+		return nil
+	}
+
+	return NewPoll(b.Question, b.Options, b.MultipleChoice, b.Anonymous, b.CloseAt)
 }
 
 type CreateMessageResponse struct {
+	// Id of scheduled message is used for sent message too
 	MessageID string `json:"message_id"`
 }
 
 type NewMessageNotification struct {
 	MessageDTO
+
+	// Message was sent to muted chat, clients shouldn't alert user
+	Silent bool `json:"silent"`
+}
+
+type NewMentionNotification struct {
+	MessageDTO
 }
 
 // ---
@@ -81,6 +661,46 @@ type GetMessageResponse struct {
 
 type ListMessagesResponse struct {
 	Items []MessageDTO `json:"items"`
+
+	// Cursors for "before" and "after" query parameters
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// ---
+
+type AttachmentParams struct {
+	ID     string `uri:"id" binding:"id"`
+	FileID string `uri:"file_id" binding:"id"`
+}
+
+type GetAttachmentResponse struct {
+	filedomain.FileDTO
+}
+
+// ---
+
+type ForwardTarget struct {
+	PeerID   string `json:"peer_id" binding:"id"`
+	PeerType string `json:"peer_type" binding:"oneof=user group"`
+}
+
+type ForwardMessageRequestBody struct {
+	Targets []ForwardTarget `json:"targets" binding:"min=1,max=10,dive"`
+}
+
+type ForwardMessageResponse struct {
+	MessageIDs []string `json:"message_ids"`
+}
+
+// ---
+
+type ListThreadMessagesResponse struct {
+	Items []MessageDTO `json:"items"`
+
+	// Cursors for "before" and "after" query parameters
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 // ---
@@ -89,28 +709,206 @@ type UpdateMessageRequestBody struct {
 	Text string `json:"text" binding:"min=1,max=1000"`
 }
 
+type GetMessageHistoryResponse struct {
+	// Previous versions of message text, from oldest to newest
+	Revisions []RevisionDTO `json:"revisions"`
+}
+
 type UpdateMessageNotification struct {
 	MessageDTO
+
+	// Presented only if message was updated by reaction
+	Reaction *ReactionUpdateDTO `json:"reaction,omitempty"`
+
+	// Presented only if message was updated by poll vote
+	Vote *VoteUpdateDTO `json:"vote,omitempty"`
+}
+
+// ---
+
+type ReactionParams struct {
+	ID    string `uri:"id" binding:"id"`
+	Emoji string `uri:"emoji" binding:"emoji"`
+}
+
+type ReactionUpdateDTO struct {
+	UserID  string `json:"user_id"`
+	Emoji   string `json:"emoji"`
+	Reacted bool   `json:"reacted"`
+}
+
+// ---
+
+type VotePollRequestBody struct {
+	// Previous choice of user is replaced
+	OptionIDs []int `json:"option_ids" binding:"min=1,max=10,dive,min=0"`
+}
+
+type ListPollVotesResponse struct {
+	// For anonymous polls only own vote is returned
+	Items []PollVoteDTO `json:"items"`
+}
+
+type VoteUpdateDTO struct {
+	// Empty for anonymous polls, except notifications for voter
+	UserID    string `json:"user_id,omitempty"`
+	OptionIDs []int  `json:"option_ids,omitempty"`
+
+	// False if vote was retracted
+	Voted bool `json:"voted"`
+}
+
+// ---
+
+type DeleteMessageRequestQuery struct {
+	// Delete message for all participants, otherwise only for current user
+	ForEveryone bool `form:"for_everyone"`
+}
+
+type DeleteMessageNotification struct {
+	ID       string `json:"id"`
+	UserID   string `json:"user_id"`
+	PeerID   string `json:"peer_id"`
+	PeerType string `json:"peer_type"`
+
+	ForEveryone bool `json:"for_everyone"`
 }
 
 // ---
 
 type ListChatsRequestQuery struct {
 	PeerType string `form:"peer_type" binding:"omitempty,oneof=user group"`
+	Archived *bool  `form:"archived"`
+	Muted    *bool  `form:"muted"`
+	FolderID string `form:"folder_id" binding:"omitempty,id"`
 
 	domain.PaginationQuery
 }
 
 type ListChatsResponse struct {
-	Items []ChatDTO `json:"items"`
+	// Pinned chats are returned only for the first page, they are not counted in limit
+	Pinned []ChatDTO `json:"pinned,omitempty"`
+	Items  []ChatDTO `json:"items"`
+
+	// Cursors for "before" and "after" query parameters
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 // ---
 
+type SearchMessagesRequestQuery struct {
+	Text string `form:"q" binding:"min=1,max=100"`
+
+	// Search only in provided chat
+	PeerID   string `form:"peer_id" binding:"omitempty,id,required_with=PeerType"`
+	PeerType string `form:"peer_type" binding:"omitempty,oneof=user group,required_with=PeerID"`
+
+	// Sender of messages
+	UserID string `form:"user_id" binding:"omitempty,id"`
+
+	// Date range in RFC3339 format
+	From time.Time `form:"from"`
+	To   time.Time `form:"to" binding:"omitempty,gtfield=From"`
+
+	domain.PaginationQuery
+}
+
+type SearchMessagesResponse struct {
+	Items []MessageSearchResultDTO `json:"items"`
+
+	// Cursors for "before" and "after" query parameters
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// ---
+
+type UpdateChatReadRequestQuery struct {
+	// Messages are read up to this message including it, otherwise all messages are read
+	MessageID string `form:"message_id" binding:"omitempty,id"`
+}
+
 type UpdateChatReadNotification struct {
 	UserID   string `json:"user_id"`
 	PeerID   string `json:"peer_id"`
 	PeerType string `json:"peer_type"`
+
+	// Last read message, all previous messages are read too
+	MessageID string    `json:"message_id"`
+	ReadAt    time.Time `json:"read_at"`
+}
+
+// ---
+
+type AckDeliveryRequestBody struct {
+	MessageIDs []string `json:"message_ids" binding:"min=1,max=100,dive,id"`
+}
+
+type UpdateDeliveryNotification struct {
+	MessageID string    `json:"message_id"`
+	PeerID    string    `json:"peer_id"`
+	PeerType  string    `json:"peer_type"`
+	UserID    string    `json:"user_id"`
+	DeliverAt time.Time `json:"deliver_at"`
+}
+
+type ListMessageReceiptsResponse struct {
+	DeliveredTo []ReceiptDTO `json:"delivered_to"`
+	ReadBy      []ReceiptDTO `json:"read_by"`
+}
+
+// ---
+
+type UpdateChatSettingsRequestBody struct {
+	// Chat is unmuted if empty
+	MutedUntil *time.Time `json:"muted_until"`
+	Archived   bool       `json:"archived"`
+	PinOrder   int64      `json:"pin_order" binding:"min=0"`
+}
+
+type UpdateChatSettingsNotification struct {
+	PeerID   string `json:"peer_id"`
+	PeerType string `json:"peer_type"`
+
+	ChatSettingsDTO
+}
+
+// ---
+
+type UpdateDraftRequestBody struct {
+	Text      string `json:"text" binding:"required_without=ReplyToID,max=1000"`
+	ReplyToID string `json:"reply_to_id" binding:"omitempty,id"`
+}
+
+type GetDraftResponse struct {
+	DraftDTO
+}
+
+type UpdateDraftNotification struct {
+	PeerID   string `json:"peer_id"`
+	PeerType string `json:"peer_type"`
+
+	// Absent if draft was deleted
+	Draft *DraftDTO `json:"draft"`
+}
+
+// ---
+
+type GetChatTTLResponse struct {
+	ChatTTLDTO
+}
+
+type UpdateChatTTLRequestBody struct {
+	// Lifetime of new messages in seconds, zero disables expiration
+	TTL int64 `json:"ttl" binding:"min=0"`
+}
+
+type UpdateChatTTLNotification struct {
+	PeerID   string `json:"peer_id"`
+	PeerType string `json:"peer_type"`
+
+	ChatTTLDTO
 }
 
 // ---
@@ -126,3 +924,172 @@ type UpdateChatTypingNotification struct {
 
 	Typing bool `json:"typing"`
 }
+
+// ---
+
+type PinParams struct {
+	PeerParams
+
+	MessageID string `uri:"message_id" binding:"id"`
+}
+
+type ListPinsResponse struct {
+	Items []PinDTO `json:"items"`
+}
+
+type UpdatePinNotification struct {
+	// User, who pinned or unpinned message
+	UserID string `json:"user_id"`
+
+	PeerID   string `json:"peer_id"`
+	PeerType string `json:"peer_type"`
+
+	MessageID string `json:"message_id"`
+	Pinned    bool   `json:"pinned"`
+}
+
+// ---
+
+type CreateGroupRequestBody struct {
+	Name    string   `json:"name" binding:"min=1,max=100"`
+	UserIDs []string `json:"user_ids" binding:"max=100,dive,id"`
+}
+
+type CreateGroupResponse struct {
+	GroupID string `json:"group_id"`
+}
+
+// ---
+
+type GetGroupResponse struct {
+	GroupDTO
+}
+
+// ---
+
+type UpdateGroupRequestBody struct {
+	Name string `json:"name" binding:"min=1,max=100"`
+}
+
+// ---
+
+type GroupMemberParams struct {
+	GroupParams
+
+	UserID string `uri:"user_id" binding:"id"`
+}
+
+type GroupMemberNotification struct {
+	GroupID string `json:"group_id"`
+
+	// User, who made changes
+	ActorID string `json:"actor_id"`
+
+	// One of: added, joined, removed, left, role_updated
+	Action string `json:"action"`
+
+	Member GroupMemberDTO `json:"member"`
+}
+
+// ---
+
+type ListGroupMembersResponse struct {
+	Items []GroupMemberDTO `json:"items"`
+}
+
+// ---
+
+type AddGroupMemberRequestBody struct {
+	UserID string `json:"user_id" binding:"id"`
+}
+
+// ---
+
+type UpdateGroupMemberRoleRequestBody struct {
+	Role string `json:"role" binding:"oneof=admin member"`
+}
+
+// ---
+
+type TransferGroupOwnershipRequestBody struct {
+	UserID string `json:"user_id" binding:"id"`
+}
+
+// ---
+
+type GroupInviteParams struct {
+	GroupParams
+
+	Token string `uri:"token" binding:"token"`
+}
+
+type CreateGroupInviteRequestBody struct {
+	// Invite lifetime in seconds, from 1 minute to 30 days
+	Lifetime int64 `json:"lifetime" binding:"min=60,max=2592000"`
+
+	// Zero means unlimited uses
+	MaxUses int64 `json:"max_uses" binding:"min=0,max=10000"`
+}
+
+type CreateGroupInviteResponse struct {
+	GroupInviteDTO
+}
+
+type ListGroupInvitesResponse struct {
+	Items []GroupInviteDTO `json:"items"`
+}
+
+// ---
+
+type JoinGroupRequestParams struct {
+	Token string `uri:"token" binding:"token"`
+}
+
+type JoinGroupResponse struct {
+	GroupID string `json:"group_id"`
+}
+
+// ---
+
+type FolderParams struct {
+	ID string `uri:"id" binding:"id"`
+}
+
+// FolderRequestBody is used for both creating and updating folder
+type FolderRequestBody struct {
+	Name      string          `json:"name" binding:"min=1,max=100"`
+	Chats     []FolderChatDTO `json:"chats" binding:"max=200,dive"`
+	PeerTypes []string        `json:"peer_types" binding:"max=2,dive,oneof=user group"`
+	Unread    bool            `json:"unread"`
+	UserIDs   []string        `json:"user_ids" binding:"max=20,dive,id"`
+}
+
+type CreateFolderResponse struct {
+	FolderID string `json:"folder_id"`
+}
+
+type ListFoldersResponse struct {
+	Items []FolderDTO `json:"items"`
+}
+
+type ListFoldersUnreadResponse struct {
+	Items []FolderUnreadDTO `json:"items"`
+}
+
+// ---
+
+type ListScheduledMessagesRequestQuery struct {
+	// Messages only from provided chat
+	PeerID   string `form:"peer_id" binding:"omitempty,id,required_with=PeerType"`
+	PeerType string `form:"peer_type" binding:"omitempty,oneof=user group,required_with=PeerID"`
+}
+
+type ListScheduledMessagesResponse struct {
+	Items []ScheduledMessageDTO `json:"items"`
+}
+
+type UpdateScheduledMessageRequestBody struct {
+	// Text can be empty only for messages with attachments, polls can't have text
+	Text   string    `json:"text" binding:"max=1000"`
+	SendAt time.Time `json:"send_at" binding:"required"`
+}
@@ -27,3 +27,228 @@ func ErrChatsNotFound() *domain.Error {
 		Name: "ERR_CHATS_NOT_FOUND",
 	}
 }
+
+func ErrGroupNotFound() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusNotFound,
+
+		Code: ErrIndex + 3,
+		Name: "ERR_GROUP_NOT_FOUND",
+	}
+}
+
+func ErrGroupMemberNotFound() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusNotFound,
+
+		Code: ErrIndex + 4,
+		Name: "ERR_GROUP_MEMBER_NOT_FOUND",
+	}
+}
+
+func ErrGroupMemberAlreadyExists() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusConflict,
+
+		Code: ErrIndex + 5,
+		Name: "ERR_GROUP_MEMBER_ALREADY_EXISTS",
+	}
+}
+
+func ErrGroupOwnerCannotLeave() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusConflict,
+
+		Code: ErrIndex + 6,
+		Name: "ERR_GROUP_OWNER_CANNOT_LEAVE",
+	}
+}
+
+func ErrGroupInviteNotFound() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusNotFound,
+
+		Code: ErrIndex + 7,
+		Name: "ERR_GROUP_INVITE_NOT_FOUND",
+	}
+}
+
+func ErrMessageDeleteWindowExpired() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusForbidden,
+
+		Code: ErrIndex + 8,
+		Name: "ERR_MESSAGE_DELETE_WINDOW_EXPIRED",
+	}
+}
+
+func ErrMessageReferenceInvalid() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusBadRequest,
+
+		Code: ErrIndex + 9,
+		Name: "ERR_MESSAGE_REFERENCE_INVALID",
+	}
+}
+
+func ErrPinsLimitExceeded() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusConflict,
+
+		Code: ErrIndex + 10,
+		Name: "ERR_PINS_LIMIT_EXCEEDED",
+	}
+}
+
+func ErrMessageAlreadyPinned() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusConflict,
+
+		Code: ErrIndex + 11,
+		Name: "ERR_MESSAGE_ALREADY_PINNED",
+	}
+}
+
+func ErrPinNotFound() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusNotFound,
+
+		Code: ErrIndex + 12,
+		Name: "ERR_PIN_NOT_FOUND",
+	}
+}
+
+func ErrMessageEditWindowExpired() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusForbidden,
+
+		Code: ErrIndex + 13,
+		Name: "ERR_MESSAGE_EDIT_WINDOW_EXPIRED",
+	}
+}
+
+func ErrPinnedChatsLimitExceeded() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusConflict,
+
+		Code: ErrIndex + 14,
+		Name: "ERR_PINNED_CHATS_LIMIT_EXCEEDED",
+	}
+}
+
+func ErrFolderNotFound() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusNotFound,
+
+		Code: ErrIndex + 15,
+		Name: "ERR_FOLDER_NOT_FOUND",
+	}
+}
+
+func ErrFoldersLimitExceeded() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusConflict,
+
+		Code: ErrIndex + 16,
+		Name: "ERR_FOLDERS_LIMIT_EXCEEDED",
+	}
+}
+
+func ErrDraftNotFound() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusNotFound,
+
+		Code: ErrIndex + 17,
+		Name: "ERR_DRAFT_NOT_FOUND",
+	}
+}
+
+func ErrScheduledMessageNotFound() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusNotFound,
+
+		Code: ErrIndex + 18,
+		Name: "ERR_SCHEDULED_MESSAGE_NOT_FOUND",
+	}
+}
+
+func ErrScheduledMessagesLimitExceeded() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusConflict,
+
+		Code: ErrIndex + 19,
+		Name: "ERR_SCHEDULED_MESSAGES_LIMIT_EXCEEDED",
+	}
+}
+
+func ErrSendTimeInvalid() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusBadRequest,
+
+		Code: ErrIndex + 20,
+		Name: "ERR_SEND_TIME_INVALID",
+	}
+}
+
+func ErrMessageTTLInvalid() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusBadRequest,
+
+		Code: ErrIndex + 21,
+		Name: "ERR_MESSAGE_TTL_INVALID",
+	}
+}
+
+func ErrPollNotFound() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusNotFound,
+
+		Code: ErrIndex + 22,
+		Name: "ERR_POLL_NOT_FOUND",
+	}
+}
+
+func ErrPollClosed() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusConflict,
+
+		Code: ErrIndex + 23,
+		Name: "ERR_POLL_CLOSED",
+	}
+}
+
+func ErrPollInvalid() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusBadRequest,
+
+		Code: ErrIndex + 24,
+		Name: "ERR_POLL_INVALID",
+	}
+}
+
+func ErrPollOptionsInvalid() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusBadRequest,
+
+		Code: ErrIndex + 25,
+		Name: "ERR_POLL_OPTIONS_INVALID",
+	}
+}
+
+func ErrPollVoteNotFound() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusNotFound,
+
+		Code: ErrIndex + 26,
+		Name: "ERR_POLL_VOTE_NOT_FOUND",
+	}
+}
+
+func ErrReceiptsBusy() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusServiceUnavailable,
+
+		Code: ErrIndex + 27,
+		Name: "ERR_RECEIPTS_BUSY",
+	}
+}
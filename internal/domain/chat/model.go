@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/undefined7887/harmony-backend/internal/domain"
+	filedomain "github.com/undefined7887/harmony-backend/internal/domain/file"
+	"github.com/undefined7887/harmony-backend/internal/util"
 )
 
 const (
@@ -11,11 +13,34 @@ const (
 	PeerTypeGroup = "group"
 )
 
+const (
+	MessagePreviewTextSize = 100
+)
+
+const (
+	MessageKindText = "text"
+	MessageKindPoll = "poll"
+)
+
+const (
+	GroupRoleOwner  = "owner"
+	GroupRoleAdmin  = "admin"
+	GroupRoleMember = "member"
+)
+
+const (
+	GroupActionAdded       = "added"
+	GroupActionJoined      = "joined"
+	GroupActionRemoved     = "removed"
+	GroupActionLeft        = "left"
+	GroupActionRoleUpdated = "role_updated"
+)
+
 type Chat struct {
 	ID   string `bson:"_id"`
 	Type string `bson:"type"`
 
-	// For future use in groups
+	// Group name, empty for user chats
 	Name string `bson:"name,omitempty"`
 
 	// Last message from chat
@@ -23,6 +48,85 @@ type Chat struct {
 
 	// Unread messages count in chat
 	UnreadCount int64 `bson:"unread_count"`
+
+	// Unread messages count, where current user is mentioned
+	UnreadMentionsCount int64 `bson:"unread_mentions_count"`
+
+	// Latest pinned message
+	PinnedMessage *Message `bson:"pinned_message,omitempty"`
+
+	// Unsent message of current user
+	Draft *Draft `bson:"draft,omitempty"`
+
+	// Settings of current user
+	ChatSettings `bson:",inline"`
+}
+
+// ChatSettings are preferences of user in chat
+type ChatSettings struct {
+	// New messages are delivered silently until this time, except mentions
+	MutedUntil *time.Time `bson:"muted_until,omitempty"`
+
+	Archived bool `bson:"archived"`
+
+	// Position in pinned chats starting from 1, zero if chat is not pinned
+	PinOrder int64 `bson:"pin_order"`
+}
+
+func (s ChatSettings) Muted(now time.Time) bool {
+	return s.MutedUntil != nil && s.MutedUntil.After(now)
+}
+
+// ChatFilter is a filter of chat list, nil fields are not applied
+type ChatFilter struct {
+	PeerType string
+
+	Archived *bool
+	Muted    *bool
+
+	Folder *FolderFilter
+}
+
+// Folder is a named set of chats of user
+type Folder struct {
+	ID     string `bson:"_id"`
+	UserID string `bson:"user_id"`
+	Name   string `bson:"name"`
+
+	// Explicitly included chats
+	Chats []FolderChat `bson:"chats"`
+
+	// Rules of inclusion, chat is included if it matches any of them.
+	// All chats of provided peer types
+	PeerTypes []string `bson:"peer_types"`
+
+	// All chats with unread messages
+	Unread bool `bson:"unread"`
+
+	// Chats with provided users and groups, where they are members
+	UserIDs []string `bson:"user_ids"`
+
+	CreatedAt time.Time `bson:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+type FolderChat struct {
+	PeerID   string `bson:"peer_id"`
+	PeerType string `bson:"peer_type"`
+}
+
+// FolderFilter is a folder with resolved rules, chat is matched if it matches any of fields
+type FolderFilter struct {
+	PeerIDs   []string
+	PeerTypes []string
+	Unread    bool
+}
+
+// FolderUnread is unread totals of chats in folder
+type FolderUnread struct {
+	UnreadChatsCount    int64 `bson:"unread_chats_count"`
+	UnreadCount         int64 `bson:"unread_count"`
+	UnreadMentionsCount int64 `bson:"unread_mentions_count"`
 }
 
 type Message struct {
@@ -38,18 +142,456 @@ type Message struct {
 	// Internal use only
 	ChatID string `bson:"chat_id"`
 
+	// One of: text, poll. Empty for messages created before kinds were introduced
+	Kind string `bson:"kind,omitempty"`
+
+	// For polls text is the same as question, so previews and search work for all kinds
 	Text   string `bson:"text"`
 	Edited bool   `bson:"edited"`
 
-	Attachments []string `bson:"attachments,omitempty"`
+	// Time of last edit, previous versions of text are stored as revisions
+	EditedAt *time.Time `bson:"edited_at,omitempty"`
+
+	// Chat participants, mentioned in text
+	MentionUserIDs []string `bson:"mention_user_ids,omitempty"`
+
+	Attachments []Attachment `bson:"attachments,omitempty"`
+
+	// Compact copy of quoted message
+	ReplyTo *MessagePreview `bson:"reply_to,omitempty"`
+
+	// Original message, if this message was forwarded
+	ForwardedFrom *MessageForward `bson:"forwarded_from,omitempty"`
+
+	// Root message of thread, which this message belongs to.
+	// Thread messages are not shown in chat timeline
+	ThreadRootID string `bson:"thread_root_id,omitempty"`
+
+	// Presented only for thread root messages
+	ThreadReplyCount  int64      `bson:"thread_reply_count,omitempty"`
+	ThreadLastReplyAt *time.Time `bson:"thread_last_reply_at,omitempty"`
+
+	// Emoji to ids of users, who reacted with it
+	Reactions map[string][]string `bson:"reactions,omitempty"`
+
+	// Message was deleted for all participants, content is erased
+	Deleted bool `bson:"deleted"`
+
+	// Users, who deleted this message only for themselves
+	DeletedUserIDs []string `bson:"deleted_user_ids,omitempty"`
+
+	// Delivery receipts of participants, except sender.
+	// Reads are stored separately as read states of chat
+	Deliveries []Receipt `bson:"deliveries,omitempty"`
+
+	// Presented only for poll messages, erased when message is deleted
+	Poll *Poll `bson:"poll,omitempty"`
+
+	// Message is deleted for all participants after this time, see chat TTL
+	ExpireAt *time.Time `bson:"expire_at,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+type Poll struct {
+	Question string       `bson:"question"`
+	Options  []PollOption `bson:"options"`
+
+	MultipleChoice bool `bson:"multiple_choice"`
+
+	// Voters of anonymous polls are visible only to themselves
+	Anonymous bool `bson:"anonymous"`
+
+	// Votes are not accepted after this time
+	CloseAt *time.Time `bson:"close_at,omitempty"`
+
+	// Number of users, who voted for at least one option
+	VoterCount int64 `bson:"voter_count"`
+}
+
+// NewPoll returns poll without votes, options are identified by their positions
+func NewPoll(question string, options []string, multipleChoice, anonymous bool, closeAt *time.Time) *Poll {
+	poll := &Poll{
+		Question:       question,
+		Options:        make([]PollOption, len(options)),
+		MultipleChoice: multipleChoice,
+		Anonymous:      anonymous,
+		CloseAt:        closeAt,
+	}
+
+	for i, text := range options {
+		poll.Options[i] = PollOption{
+			ID:   i,
+			Text: text,
+		}
+	}
+
+	return poll
+}
+
+// Copy returns the same poll without votes, used for forwarding
+func (p Poll) Copy() *Poll {
+	return NewPoll(
+		p.Question,
+		util.Map(p.Options, func(option PollOption) string {
+			return option.Text
+		}),
+		p.MultipleChoice,
+		p.Anonymous,
+		p.CloseAt,
+	)
+}
+
+func (p Poll) Closed(now time.Time) bool {
+	return p.CloseAt != nil && !p.CloseAt.After(now)
+}
+
+func (p Poll) HasOption(id int) bool {
+	return id >= 0 && id < len(p.Options)
+}
+
+type PollOption struct {
+	ID   int    `bson:"id"`
+	Text string `bson:"text"`
+
+	VoteCount int64 `bson:"vote_count"`
+}
+
+// PollVote is a choice of user in poll, counters of poll are updated together with votes
+type PollVote struct {
+	ID        string `bson:"_id"`
+	MessageID string `bson:"message_id"`
+	UserID    string `bson:"user_id"`
+
+	OptionIDs []int `bson:"option_ids"`
+
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func PollVoteID(messageID, userID string) string {
+	return messageID + ":" + userID
+}
+
+// FileCleanup is an attachment of erased message, file is deleted by reaper if it's not used anymore
+type FileCleanup struct {
+	// Same as file id
+	ID string `bson:"_id"`
+
+	CreatedAt time.Time `bson:"created_at"`
+
+	// Set, when file is not used anymore, so it can't be attached again
+	Deleting bool `bson:"deleting,omitempty"`
+}
+
+// ChatTTL is a lifetime of new messages in chat, shared by all participants
+type ChatTTL struct {
+	// Same as chat id
+	ID string `bson:"_id"`
+
+	// Zero if messages don't expire
+	TTL time.Duration `bson:"ttl"`
+
+	// User, who changed lifetime
+	UserID string `bson:"user_id"`
+
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// ChatMember is a chat list entry of participant, updated together with messages
+type ChatMember struct {
+	ID     string `bson:"_id"`
+	ChatID string `bson:"chat_id"`
+	UserID string `bson:"user_id"`
+
+	// Peer of chat for this user: other user for user chats and group for group chats
+	PeerID   string `bson:"peer_id"`
+	PeerType string `bson:"peer_type"`
+
+	// Last message visible for user, absent if chat has no messages yet.
+	// Creation time of message is used for ordering chat list
+	MessageID        string     `bson:"message_id,omitempty"`
+	MessageCreatedAt *time.Time `bson:"message_created_at,omitempty"`
+
+	UnreadCount         int64 `bson:"unread_count"`
+	UnreadMentionsCount int64 `bson:"unread_mentions_count"`
+
+	ChatSettings `bson:",inline"`
+}
+
+func ChatMemberID(chatID, userID string) string {
+	return chatID + ":" + userID
+}
+
+// ScheduledMessage is a message, which is sent at provided time
+type ScheduledMessage struct {
+	// Sent message gets the same id, so it's never sent twice
+	ID     string `bson:"_id"`
+	UserID string `bson:"user_id"`
+
+	PeerID   string `bson:"peer_id"`
+	PeerType string `bson:"peer_type"`
+	ChatID   string `bson:"chat_id"`
+
+	Text          string   `bson:"text"`
+	AttachmentIDs []string `bson:"attachment_ids,omitempty"`
+	ReplyToID     string   `bson:"reply_to_id,omitempty"`
+	ThreadRootID  string   `bson:"thread_root_id,omitempty"`
+	Poll          *Poll    `bson:"poll,omitempty"`
+
+	SendAt time.Time `bson:"send_at"`
 
-	// Users, who read this message
-	ReadUserIDs []string `bson:"read_user_ids"`
+	// Message is being sent by one of instances until lease is expired
+	LockedUntil *time.Time `bson:"locked_until,omitempty"`
 
 	CreatedAt time.Time `bson:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at"`
 }
 
+// Draft is an unsent message of user in chat, synced between user sessions
+type Draft struct {
+	ID     string `bson:"_id"`
+	ChatID string `bson:"chat_id"`
+	UserID string `bson:"user_id"`
+
+	PeerID   string `bson:"peer_id"`
+	PeerType string `bson:"peer_type"`
+
+	Text      string `bson:"text"`
+	ReplyToID string `bson:"reply_to_id,omitempty"`
+
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+func DraftID(chatID, userID string) string {
+	return ChatMemberID(chatID, userID)
+}
+
+// ReadState is a read watermark of user in chat,
+// all messages sent not later than last read message are considered read
+type ReadState struct {
+	ID     string `bson:"_id"`
+	ChatID string `bson:"chat_id"`
+	UserID string `bson:"user_id"`
+
+	// Last read message
+	MessageID        string    `bson:"message_id"`
+	MessageCreatedAt time.Time `bson:"message_created_at"`
+
+	// Time of last read
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+func ReadStateID(chatID, userID string) string {
+	return ChatMemberID(chatID, userID)
+}
+
+// Reads returns receipts of participants, who read message according to provided read states.
+// Only the last read is stored per chat, so time of receipt is time of the last read in chat,
+// which can be later than the moment when message was read
+func (m Message) Reads(states []ReadState) []Receipt {
+	var receipts []Receipt
+
+	for _, state := range states {
+		if state.ChatID != m.ChatID || state.UserID == m.UserID || state.MessageCreatedAt.Before(m.CreatedAt) {
+			continue
+		}
+
+		receipts = append(receipts, Receipt{
+			UserID:    state.UserID,
+			CreatedAt: state.UpdatedAt,
+		})
+	}
+
+	return receipts
+}
+
+// ReadSummary is the latest read watermark in chat among participants except one user
+type ReadSummary struct {
+	ChatID           string    `bson:"_id"`
+	MessageCreatedAt time.Time `bson:"message_created_at"`
+}
+
+// ReadIn reports whether message is read by anyone according to provided read summaries,
+// so summaries must not include sender of message
+func (m Message) ReadIn(summaries []ReadSummary) bool {
+	for _, summary := range summaries {
+		if summary.ChatID == m.ChatID && !summary.MessageCreatedAt.Before(m.CreatedAt) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Receipt struct {
+	UserID    string    `bson:"user_id"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// MessageReceipt is a receipt, which must be added to message
+type MessageReceipt struct {
+	MessageID string
+	Receipt   Receipt
+}
+
+// Attachment is a snapshot of uploaded file
+type Attachment struct {
+	ID       string `bson:"_id"`
+	Name     string `bson:"name"`
+	MimeType string `bson:"mime_type"`
+	Size     int64  `bson:"size"`
+
+	// Image metadata, updated after file processing
+	Width      int                    `bson:"width,omitempty"`
+	Height     int                    `bson:"height,omitempty"`
+	Thumbnails []filedomain.Thumbnail `bson:"thumbnails,omitempty"`
+	Processing bool                   `bson:"processing,omitempty"`
+}
+
+func NewAttachment(file filedomain.File) Attachment {
+	return Attachment{
+		ID:         file.ID,
+		Name:       file.Name,
+		MimeType:   file.MimeType,
+		Size:       file.Size,
+		Width:      file.Width,
+		Height:     file.Height,
+		Thumbnails: file.Thumbnails,
+		Processing: file.Processing,
+	}
+}
+
+type MessagePreview struct {
+	ID     string `bson:"_id"`
+	UserID string `bson:"user_id"`
+
+	// Text is truncated to MessagePreviewTextSize runes
+	Text    string `bson:"text"`
+	Deleted bool   `bson:"deleted"`
+}
+
+func NewMessagePreview(message Message) *MessagePreview {
+	text := []rune(message.Text)
+
+	if len(text) > MessagePreviewTextSize {
+		text = text[:MessagePreviewTextSize]
+	}
+
+	return &MessagePreview{
+		ID:      message.ID,
+		UserID:  message.UserID,
+		Text:    string(text),
+		Deleted: message.Deleted,
+	}
+}
+
+type MessageForward struct {
+	MessageID string `bson:"message_id"`
+
+	// Original sender
+	UserID string `bson:"user_id"`
+
+	// Original chat
+	ChatID   string `bson:"chat_id"`
+	PeerType string `bson:"peer_type"`
+
+	// Presented only for group chats, so user chats stay private
+	PeerID string `bson:"peer_id,omitempty"`
+}
+
+func NewMessageForward(message Message) *MessageForward {
+	// Message was already forwarded, keeping the original source
+	if message.ForwardedFrom != nil {
+		return message.ForwardedFrom
+	}
+
+	forward := &MessageForward{
+		MessageID: message.ID,
+		UserID:    message.UserID,
+		ChatID:    message.ChatID,
+		PeerType:  message.PeerType,
+	}
+
+	if message.PeerType == PeerTypeGroup {
+		forward.PeerID = message.PeerID
+	}
+
+	return forward
+}
+
+type Pin struct {
+	ID     string `bson:"_id"`
+	ChatID string `bson:"chat_id"`
+
+	MessageID string `bson:"message_id"`
+
+	// User, who pinned message
+	UserID string `bson:"user_id"`
+
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// Revision is a previous version of message text
+type Revision struct {
+	ID        string `bson:"_id"`
+	MessageID string `bson:"message_id"`
+
+	Text string `bson:"text"`
+
+	// Time, when this version was written
+	CreatedAt time.Time `bson:"created_at"`
+
+	// Time, when this version was replaced by edit
+	ReplacedAt time.Time `bson:"replaced_at"`
+}
+
+type Group struct {
+	ID string `bson:"_id"`
+
+	// Creator of group
+	UserID string `bson:"user_id"`
+
+	Name string `bson:"name"`
+
+	CreatedAt time.Time `bson:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+type GroupMember struct {
+	ID      string `bson:"_id"`
+	GroupID string `bson:"group_id"`
+	UserID  string `bson:"user_id"`
+
+	// One of: owner, admin, member
+	// Each group has exactly one owner
+	Role string `bson:"role"`
+
+	CreatedAt time.Time `bson:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+type GroupInvite struct {
+	// Random token, used as a part of invite link
+	ID string `bson:"_id"`
+
+	GroupID string `bson:"group_id"`
+
+	// Creator of invite
+	UserID string `bson:"user_id"`
+
+	// Zero means unlimited uses
+	MaxUses int64 `bson:"max_uses"`
+	Uses    int64 `bson:"uses"`
+
+	ExpireAt  time.Time `bson:"expire_at"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func IsGroupAdmin(role string) bool {
+	return role == GroupRoleOwner || role == GroupRoleAdmin
+}
+
 func ChatID(userID, peerID, peerType string) string {
 	var chatID string
 
@@ -1,22 +1,218 @@
 package chatdomain
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/undefined7887/harmony-backend/internal/domain"
+)
 
 type MessageRepository interface {
+	// Create returns repository.ErrFilesDeleted if some of attachments are claimed for deletion
 	Create(ctx context.Context, message *Message) (bool, error)
 
+	// ListExpired returns messages, which lifetime is over, from the oldest ones
+	ListExpired(ctx context.Context, now time.Time, limit int64) ([]Message, error)
+
+	// DeleteExpired erases expired message completely, returns false if it was deleted before.
+	// Attachments of message are recorded for cleanup in the same transaction
+	DeleteExpired(ctx context.Context, message Message, now time.Time) (bool, error)
+
+	// ListFileCleanups returns pending cleanups of attachments, from the oldest ones
+	ListFileCleanups(ctx context.Context, limit int64) ([]FileCleanup, error)
+
+	// ClaimFileCleanups deletes cleanups of files, which are still attached to messages, and returns other ones.
+	// Returned files can't be attached anymore, until their cleanups are deleted
+	ClaimFileCleanups(ctx context.Context, ids []string) ([]string, error)
+	DeleteFileCleanups(ctx context.Context, ids []string) (int64, error)
+
 	Get(ctx context.Context, id string) (Message, error)
-	List(ctx context.Context, chatID string, offset, limit int64) ([]Message, error)
 
-	UpdateText(ctx context.Context, id, userID, text string) (Message, error)
+	// ListByIDs returns messages with provided ids in any order
+	ListByIDs(ctx context.Context, ids []string) ([]Message, error)
+
+	// List returns chat messages, except thread messages and messages deleted by user
+	List(ctx context.Context, chatID, userID string, pagination domain.Pagination) (domain.Page[Message], error)
+
+	// ListThread returns thread messages, except messages deleted by user
+	ListThread(ctx context.Context, rootID, userID string, pagination domain.Pagination) (domain.Page[Message], error)
+
+	// UpdateText replaces text and mentions of message, sent by user not earlier than sentAfter,
+	// and saves previous text as revision
+	UpdateText(
+		ctx context.Context,
+		id, userID, text string,
+		mentionUserIDs []string,
+		sentAfter time.Time,
+	) (Message, error)
+
+	// ListRevisions returns previous versions of message text, sorted from oldest to newest
+	ListRevisions(ctx context.Context, id string) ([]Revision, error)
+
+	// AddDeliveries adds delivery receipts to messages, which were not delivered to receipt users yet
+	AddDeliveries(ctx context.Context, receipts []MessageReceipt) error
+
+	// UpdateAttachment replaces attachment snapshot in all messages containing it
+	UpdateAttachment(ctx context.Context, attachment Attachment) ([]Message, error)
+
+	// UpdateThread increments replies count of thread root message
+	UpdateThread(ctx context.Context, rootID string, replyAt time.Time) (Message, error)
+
+	AddReaction(ctx context.Context, id, userID, emoji string) (Message, error)
+	RemoveReaction(ctx context.Context, id, userID, emoji string) (Message, error)
+
+	// Vote replaces previous vote of user in poll and updates option counters
+	Vote(ctx context.Context, vote PollVote) (Message, error)
+
+	// RetractVote deletes vote of user in poll and updates option counters
+	RetractVote(ctx context.Context, id, userID string) (Message, error)
+
+	// ListVotes returns votes in poll, sorted from oldest to newest
+	ListVotes(ctx context.Context, id string) ([]PollVote, error)
+	GetVote(ctx context.Context, id, userID string) (PollVote, error)
+
+	// DeleteForUser hides message only for provided user
+	DeleteForUser(ctx context.Context, id, userID string) (Message, error)
+
+	// DeleteForAll erases content and revisions of message, sent by user not earlier than sentAfter.
+	// Removed attachments are recorded as file cleanups
+	DeleteForAll(ctx context.Context, id, userID string, sentAfter time.Time) (Message, error)
+}
+
+// MessageSearchRepository is a full-text search backend for messages
+type MessageSearchRepository interface {
+	// Search returns messages matching query, except deleted ones, sorted from newest to oldest
+	Search(ctx context.Context, query MessageSearchQuery, pagination domain.Pagination) (domain.Page[MessageSearchResult], error)
 }
 
 type ChatRepository interface {
-	List(
-		ctx context.Context,
-		userID, peerType string,
-		offset, limit int64,
-	) ([]Chat, error)
+	// List returns not pinned chats ordered by last message
+	List(ctx context.Context, userID string, filter ChatFilter, pagination domain.Pagination) (domain.Page[Chat], error)
+
+	// ListPinned returns pinned chats in pinning order
+	ListPinned(ctx context.Context, userID string, filter ChatFilter) ([]Chat, error)
+
+	GetMember(ctx context.Context, chatID, userID string) (ChatMember, error)
+
+	// UpdateSettings replaces chat settings of user, creating chat list entry if needed.
+	// Returns repository.ErrLimitExceeded if chat is being pinned, but user already has pinnedLimit pinned chats
+	UpdateSettings(ctx context.Context, member ChatMember, pinnedLimit int64) (ChatMember, error)
+
+	// ListMutedUserIDs returns participants, who muted chat at the moment
+	ListMutedUserIDs(ctx context.Context, chatID string, now time.Time) ([]string, error)
+
+	// GetTTL returns lifetime of new messages in chat
+	GetTTL(ctx context.Context, chatID string) (ChatTTL, error)
+
+	UpdateTTL(ctx context.Context, ttl ChatTTL) (ChatTTL, error)
+
+	// CountFoldersUnread returns unread totals of user chats for every filter in the same order
+	CountFoldersUnread(ctx context.Context, userID string, filters []FolderFilter) ([]FolderUnread, error)
+
+	// UpdateRead moves read watermark of user forward, returns false if state already has later message
+	UpdateRead(ctx context.Context, state ReadState) (bool, error)
+
+	// ListReads returns read states of participants, who read messages of chat sent at sentAt or later
+	ListReads(ctx context.Context, chatID string, sentAt time.Time) ([]ReadState, error)
+
+	// ListReadSummaries returns the latest read watermarks of provided chats among participants except user
+	ListReadSummaries(ctx context.Context, userID string, chatIDs []string) ([]ReadSummary, error)
+
+	// Rebuild regenerates chat lists of all users from messages, progress is called with number of processed entries
+	Rebuild(ctx context.Context, progress func(count int64)) error
+}
+
+type GroupRepository interface {
+	Create(ctx context.Context, group *Group, members []GroupMember) (bool, error)
+
+	Get(ctx context.Context, id string) (Group, error)
+
+	UpdateName(ctx context.Context, id, name string) (Group, error)
+
+	AddMember(ctx context.Context, member *GroupMember) (bool, error)
+
+	GetMember(ctx context.Context, groupID, userID string) (GroupMember, error)
+	ListMembers(ctx context.Context, groupID string) ([]GroupMember, error)
+
+	// ListUserGroupIDs returns ids of all groups where user is a member
+	ListUserGroupIDs(ctx context.Context, userID string) ([]string, error)
+
+	// UpdateMemberRole changes role of any member except owner
+	UpdateMemberRole(ctx context.Context, groupID, userID, role string) (GroupMember, error)
+
+	// TransferOwnership makes new owner from member, previous owner becomes admin
+	TransferOwnership(ctx context.Context, groupID, ownerID, newOwnerID string) (bool, error)
+
+	// DeleteMember deletes any member except owner
+	DeleteMember(ctx context.Context, groupID, userID string) (bool, error)
+
+	CreateInvite(ctx context.Context, invite *GroupInvite) (bool, error)
+
+	GetInvite(ctx context.Context, id string) (GroupInvite, error)
+	ListInvites(ctx context.Context, groupID string) ([]GroupInvite, error)
+
+	// Join uses invite and adds member in the same transaction, returns false if user is already a member.
+	// Invite is used only if it's not expired and uses limit is not reached
+	Join(ctx context.Context, inviteID string, member *GroupMember) (bool, error)
+
+	DeleteInvite(ctx context.Context, groupID, id string) (bool, error)
+}
+
+type PinRepository interface {
+	// Create returns repository.ErrLimitExceeded if chat already has limit pins
+	Create(ctx context.Context, pin *Pin, limit int64) (bool, error)
+
+	// List returns chat pins in pinning order
+	List(ctx context.Context, chatID string) ([]Pin, error)
+
+	Delete(ctx context.Context, chatID, messageID string) (bool, error)
+
+	// DeleteByMessage deletes pin of message from any chat
+	DeleteByMessage(ctx context.Context, messageID string) (bool, error)
+}
+
+type FolderRepository interface {
+	// Create returns repository.ErrLimitExceeded if user already has limit folders
+	Create(ctx context.Context, folder *Folder, limit int64) (bool, error)
+
+	Get(ctx context.Context, id, userID string) (Folder, error)
+
+	// List returns folders of user in creation order
+	List(ctx context.Context, userID string) ([]Folder, error)
+
+	Update(ctx context.Context, folder Folder) (Folder, error)
+
+	Delete(ctx context.Context, id, userID string) (bool, error)
+}
+
+type DraftRepository interface {
+	// Update creates or replaces draft of user in chat
+	Update(ctx context.Context, draft Draft) (Draft, error)
+
+	Get(ctx context.Context, chatID, userID string) (Draft, error)
+
+	Delete(ctx context.Context, chatID, userID string) (bool, error)
+}
+
+type ScheduledMessageRepository interface {
+	// Create returns repository.ErrLimitExceeded if user already has limit pending messages
+	// and repository.ErrFilesDeleted if some of attachments are claimed for deletion
+	Create(ctx context.Context, message *ScheduledMessage, limit int64) (bool, error)
+
+	Get(ctx context.Context, id, userID string) (ScheduledMessage, error)
+
+	// List returns scheduled messages of user in sending order, chatID is optional
+	List(ctx context.Context, userID, chatID string) ([]ScheduledMessage, error)
+
+	// Update changes message, which is not being sent at the moment
+	Update(ctx context.Context, id, userID, text string, sendAt, now time.Time) (ScheduledMessage, error)
+
+	// Delete cancels message, which is not being sent at the moment
+	Delete(ctx context.Context, id, userID string, now time.Time) (bool, error)
+
+	// Claim locks due message until lease is expired, so other instances don't send it
+	Claim(ctx context.Context, now, lockedUntil time.Time) (ScheduledMessage, error)
 
-	UpdateRead(ctx context.Context, userID, chatID string) (int64, error)
+	// DeleteSent deletes claimed message after sending
+	DeleteSent(ctx context.Context, id string) (bool, error)
 }
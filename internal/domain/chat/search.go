@@ -0,0 +1,135 @@
+package chatdomain
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/samber/lo"
+
+	"github.com/undefined7887/harmony-backend/internal/util"
+)
+
+const (
+	SnippetSize = 150
+)
+
+type MessageSearchQuery struct {
+	Text string
+
+	// User, who searches messages.
+	// Only user chats of this user and provided groups are searched
+	UserID   string
+	GroupIDs []string
+
+	// Optional filters
+	ChatID   string
+	SenderID string
+	From     time.Time
+	To       time.Time
+}
+
+type MessageSearchResult struct {
+	Message Message
+	Snippet Snippet
+}
+
+// Snippet is a fragment of message text with highlighted search terms
+type Snippet struct {
+	Text       string
+	Highlights []Highlight
+}
+
+// Highlight is a range of characters in snippet text, end is exclusive
+type Highlight struct {
+	Start int
+	End   int
+}
+
+// NewSnippet finds words from search text in message text,
+// and cuts fragment around the first found word if text is too long
+func NewSnippet(text, searchText string) Snippet {
+	terms := util.Map(searchTerms(searchText), strings.ToLower)
+
+	runes := []rune(text)
+
+	var highlights []Highlight
+
+	for _, word := range words(runes) {
+		if lo.Contains(terms, strings.ToLower(string(runes[word.Start:word.End]))) {
+			highlights = append(highlights, word)
+		}
+	}
+
+	if len(runes) <= SnippetSize {
+		return Snippet{
+			Text:       text,
+			Highlights: highlights,
+		}
+	}
+
+	start := 0
+
+	// Leaving some context before the first found word
+	if len(highlights) > 0 && highlights[0].Start > SnippetSize/3 {
+		start = highlights[0].Start - SnippetSize/3
+	}
+
+	// Snippet is always SnippetSize long
+	if start > len(runes)-SnippetSize {
+		start = len(runes) - SnippetSize
+	}
+
+	end := start + SnippetSize
+
+	snippet := Snippet{
+		Text: string(runes[start:end]),
+	}
+
+	for _, highlight := range highlights {
+		if highlight.Start < start || highlight.End > end {
+			continue
+		}
+
+		snippet.Highlights = append(snippet.Highlights, Highlight{
+			Start: highlight.Start - start,
+			End:   highlight.End - start,
+		})
+	}
+
+	return snippet
+}
+
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
+
+func words(runes []rune) []Highlight {
+	var (
+		result []Highlight
+		start  = -1
+	)
+
+	for i, r := range runes {
+		switch {
+		case isWordRune(r) && start < 0:
+			start = i
+
+		case !isWordRune(r) && start >= 0:
+			result = append(result, Highlight{Start: start, End: i})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		result = append(result, Highlight{Start: start, End: len(runes)})
+	}
+
+	return result
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -1,10 +1,46 @@
 package domain
 
+const (
+	PaginationDefaultLimit = 50
+	PaginationMaxLimit     = 100
+)
+
 type IdParam struct {
 	ID string `uri:"id" binding:"id"`
 }
 
 type PaginationQuery struct {
-	Offset int64 `form:"offset" binding:"min=0"`
-	Limit  int64 `form:"limit" binding:"min=0"`
+	// Deprecated, kept for compatibility. Pages shift when new items arrive, use cursors instead
+	Offset int64 `form:"offset" binding:"min=0,excluded_with=Before After"`
+
+	// Zero means PaginationDefaultLimit
+	Limit int64 `form:"limit" binding:"min=0,max=100"`
+
+	// Cursors from previous page
+	Before string `form:"before" binding:"omitempty,cursor,excluded_with=After"`
+	After  string `form:"after" binding:"omitempty,cursor"`
+}
+
+// Pagination must be called only for validated query
+func (q PaginationQuery) Pagination() Pagination {
+	pagination := Pagination{
+		Offset: q.Offset,
+		Limit:  q.Limit,
+	}
+
+	if pagination.Limit == 0 {
+		pagination.Limit = PaginationDefaultLimit
+	}
+
+	if q.Before != "" {
+		cursor, _ := ParseCursor(q.Before)
+		pagination.Before = &cursor
+	}
+
+	if q.After != "" {
+		cursor, _ := ParseCursor(q.After)
+		pagination.After = &cursor
+	}
+
+	return pagination
 }
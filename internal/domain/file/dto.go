@@ -0,0 +1,86 @@
+package filedomain
+
+import (
+	"time"
+
+	"github.com/undefined7887/harmony-backend/internal/util"
+)
+
+type FileDTO struct {
+	ID       string `json:"id"`
+	UserID   string `json:"user_id"`
+	Name     string `json:"name"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+
+	Width      int            `json:"width,omitempty"`
+	Height     int            `json:"height,omitempty"`
+	Thumbnails []ThumbnailDTO `json:"thumbnails,omitempty"`
+	Processing bool           `json:"processing"`
+
+	// Temporary download url, absent in lists
+	URL       string     `json:"url,omitempty"`
+	ExpireAt  *time.Time `json:"expire_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func MapFileDTO(file File) FileDTO {
+	return FileDTO{
+		ID:         file.ID,
+		UserID:     file.UserID,
+		Name:       file.Name,
+		MimeType:   file.MimeType,
+		Size:       file.Size,
+		Checksum:   file.Checksum,
+		Width:      file.Width,
+		Height:     file.Height,
+		Thumbnails: util.Map(file.Thumbnails, MapThumbnailDTO),
+		Processing: file.Processing,
+		CreatedAt:  file.CreatedAt,
+	}
+}
+
+type ThumbnailDTO struct {
+	Size     int    `json:"size"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	MimeType string `json:"mime_type"`
+	FileSize int64  `json:"file_size"`
+
+	// Temporary download url
+	URL string `json:"url,omitempty"`
+}
+
+func MapThumbnailDTO(thumbnail Thumbnail) ThumbnailDTO {
+	return ThumbnailDTO{
+		Size:     thumbnail.Size,
+		Width:    thumbnail.Width,
+		Height:   thumbnail.Height,
+		MimeType: thumbnail.MimeType,
+		FileSize: thumbnail.FileSize,
+	}
+}
+
+// ---
+
+type UploadFileResponse struct {
+	FileDTO
+}
+
+// ---
+
+type GetFileResponse struct {
+	FileDTO
+}
+
+// ---
+
+type DownloadFileRequestQuery struct {
+	// Size of thumbnail, zero means original file
+	Thumbnail int `form:"thumbnail" binding:"min=0"`
+
+	// Unix time
+	Expires   int64  `form:"expires" binding:"min=1"`
+	Signature string `form:"signature" binding:"base64url"`
+}
@@ -0,0 +1,47 @@
+package filedomain
+
+import (
+	"net/http"
+
+	"github.com/undefined7887/harmony-backend/internal/domain"
+)
+
+const (
+	ErrIndex = 500
+)
+
+func ErrFileNotFound() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusNotFound,
+
+		Code: ErrIndex + 1,
+		Name: "ERR_FILE(S)_NOT_FOUND",
+	}
+}
+
+func ErrFileTooLarge() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusRequestEntityTooLarge,
+
+		Code: ErrIndex + 2,
+		Name: "ERR_FILE_TOO_LARGE",
+	}
+}
+
+func ErrFileTypeNotAllowed() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusUnsupportedMediaType,
+
+		Code: ErrIndex + 3,
+		Name: "ERR_FILE_TYPE_NOT_ALLOWED",
+	}
+}
+
+func ErrFileUrlInvalid() *domain.Error {
+	return &domain.Error{
+		StatusCode: http.StatusForbidden,
+
+		Code: ErrIndex + 4,
+		Name: "ERR_FILE_URL_INVALID",
+	}
+}
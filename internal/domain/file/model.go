@@ -0,0 +1,62 @@
+package filedomain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const (
+	// NameSize is maximum length of file name in runes
+	NameSize = 255
+)
+
+// ImageTypes are types of images, which are processed after uploading
+var ImageTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+type File struct {
+	ID string `bson:"_id"`
+
+	// Owner of file
+	UserID string `bson:"user_id"`
+
+	Name     string `bson:"name"`
+	MimeType string `bson:"mime_type"`
+	Size     int64  `bson:"size"`
+
+	// Hex encoded SHA-256 of content
+	Checksum string `bson:"checksum"`
+
+	// Image metadata, presented only for processed images
+	Width      int         `bson:"width,omitempty"`
+	Height     int         `bson:"height,omitempty"`
+	Thumbnails []Thumbnail `bson:"thumbnails,omitempty"`
+
+	// File is waiting for processing
+	Processing bool `bson:"processing,omitempty"`
+
+	// File is being processed by one of instances until lease is expired
+	LockedUntil *time.Time `bson:"locked_until,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+type Thumbnail struct {
+	// Longest side limit, used for generation
+	Size int `bson:"size"`
+
+	Width    int    `bson:"width"`
+	Height   int    `bson:"height"`
+	MimeType string `bson:"mime_type"`
+	FileSize int64  `bson:"file_size"`
+}
+
+func IsImage(mimeType string) bool {
+	return lo.Contains(ImageTypes, mimeType)
+}
+
+// ThumbnailKey returns storage key of thumbnail
+func ThumbnailKey(fileID string, size int) string {
+	return fmt.Sprintf("%s_%d", fileID, size)
+}
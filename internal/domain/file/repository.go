@@ -0,0 +1,26 @@
+package filedomain
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	Create(ctx context.Context, file *File) (bool, error)
+
+	Get(ctx context.Context, id string) (File, error)
+
+	// ListByIDs returns files with provided ids in any order
+	ListByIDs(ctx context.Context, ids []string) ([]File, error)
+
+	// ListProcessing returns files, waiting for processing
+	ListProcessing(ctx context.Context) ([]File, error)
+
+	// Claim locks file, waiting for processing, until lease is expired, so other instances don't process it
+	Claim(ctx context.Context, id string, now, lockedUntil time.Time) (File, error)
+
+	// UpdateMedia saves processing results and finishes processing
+	UpdateMedia(ctx context.Context, id string, width, height int, thumbnails []Thumbnail) (File, error)
+
+	Delete(ctx context.Context, ids []string) (int64, error)
+}
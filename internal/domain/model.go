@@ -1 +1,76 @@
 package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Cursor points to item in list, sorted by creation time and id
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"i"`
+}
+
+func ParseCursor(value string) (Cursor, error) {
+	data, err := Base64.DecodeString(value)
+	if err != nil {
+		return Cursor{}, err
+	}
+
+	var cursor Cursor
+
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return Cursor{}, err
+	}
+
+	return cursor, nil
+}
+
+// String returns opaque representation of cursor, which is safe to use in URLs
+func (c Cursor) String() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		// Should never happen
+		panic(err)
+	}
+
+	return Base64.EncodeToString(data)
+}
+
+// Pagination describes requested page of list, sorted from newest to oldest items
+type Pagination struct {
+	// Deprecated offset mode, used only if cursors are not provided
+	Offset int64
+
+	Limit int64
+
+	// Items, older than Before
+	Before *Cursor
+
+	// Items, newer than After
+	After *Cursor
+}
+
+type Page[T any] struct {
+	Items []T
+
+	// Cursor for older items, empty if there are no more items
+	NextCursor string
+
+	// Cursor for newer items, empty if there are no more items
+	PrevCursor string
+}
+
+func MapPage[T, R any](page Page[T], mapper func(item T) R) Page[R] {
+	result := Page[R]{
+		Items:      make([]R, len(page.Items)),
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}
+
+	for i, item := range page.Items {
+		result.Items[i] = mapper(item)
+	}
+
+	return result
+}
@@ -27,3 +27,8 @@ type User struct {
 	CreatedAt time.Time `bson:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at"`
 }
+
+// Offline returns true if user is offline or status of user was not updated during timeout
+func (u User) Offline(now time.Time) bool {
+	return u.Status == StatusOffline || u.UpdatedAt.Before(now.Add(-UserOutdatedTimeout))
+}
@@ -8,6 +8,8 @@ type Repository interface {
 	Get(ctx context.Context, id string) (User, error)
 	GetByEmail(ctx context.Context, email string) (User, error)
 	GetByNickname(ctx context.Context, nickname string) (User, error)
+	ListByIDs(ctx context.Context, ids []string) ([]User, error)
+	ListByNicknames(ctx context.Context, nicknames []string) ([]User, error)
 
 	Exists(ctx context.Context, id string) (bool, error)
 
@@ -13,6 +13,9 @@ import (
 	"github.com/undefined7887/harmony-backend/internal/config"
 )
 
+// Documents of this collection are used only for conflicts between transactions, see Lock
+const lockCollection = "locks"
+
 func NewDatabase(config *config.Mongo) (*mongo.Database, error) {
 	clientOpts := options.Client().
 		SetDirect(config.Direct).
@@ -89,6 +92,28 @@ func Transaction[T any](
 	return result.(T), nil
 }
 
+// Lock serializes transactions, which lock the same key, so it must be called inside transaction.
+// Concurrent transaction gets write conflict and is retried after current one is committed
+func Lock(ctx context.Context, database *mongo.Database, key string) error {
+	_, err := database.
+		Collection(lockCollection).
+		UpdateOne(ctx,
+			bson.M{
+				"_id": key,
+			},
+			bson.M{
+				"$inc": bson.M{
+					"version": 1,
+				},
+			},
+			options.
+				Update().
+				SetUpsert(true),
+		)
+
+	return err
+}
+
 func TransactionNoReturn(
 	ctx context.Context,
 	database *mongo.Database,
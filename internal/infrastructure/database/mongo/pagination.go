@@ -0,0 +1,114 @@
+package mongodatabase
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/undefined7887/harmony-backend/internal/domain"
+)
+
+// PaginationStages returns aggregation stages, selecting page of documents sorted by (timeField, _id) descending.
+// One extra document is selected to detect if there are more documents, so result must be passed to NewPage
+func PaginationStages(pagination domain.Pagination, timeField string) bson.A {
+	// Newer documents are selected in ascending order, so the nearest to cursor go first
+	if pagination.After != nil {
+		return bson.A{
+			bson.M{
+				"$match": cursorFilter(*pagination.After, timeField, "$gt"),
+			},
+			bson.M{
+				"$sort": bson.D{
+					{Key: timeField, Value: 1},
+					{Key: "_id", Value: 1},
+				},
+			},
+			bson.M{
+				"$limit": pagination.Limit + 1,
+			},
+		}
+	}
+
+	var stages bson.A
+
+	if pagination.Before != nil {
+		stages = append(stages, bson.M{
+			"$match": cursorFilter(*pagination.Before, timeField, "$lt"),
+		})
+	}
+
+	stages = append(stages, bson.M{
+		"$sort": bson.D{
+			{Key: timeField, Value: -1},
+			{Key: "_id", Value: -1},
+		},
+	})
+
+	if pagination.Before == nil && pagination.Offset > 0 {
+		stages = append(stages, bson.M{
+			"$skip": pagination.Offset,
+		})
+	}
+
+	return append(stages, bson.M{
+		"$limit": pagination.Limit + 1,
+	})
+}
+
+// NewPage builds page from documents, selected with PaginationStages
+func NewPage[T any](items []T, pagination domain.Pagination, cursor func(item T) domain.Cursor) domain.Page[T] {
+	hasMore := int64(len(items)) > pagination.Limit
+
+	if hasMore {
+		items = items[:pagination.Limit]
+	}
+
+	var page domain.Page[T]
+
+	if pagination.After != nil {
+		// Restoring descending order
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+
+		page.Items = items
+
+		if len(items) > 0 {
+			// Cursor document itself is older
+			page.NextCursor = cursor(items[len(items)-1]).String()
+
+			if hasMore {
+				page.PrevCursor = cursor(items[0]).String()
+			}
+		}
+
+		return page
+	}
+
+	page.Items = items
+
+	if len(items) > 0 {
+		if hasMore {
+			page.NextCursor = cursor(items[len(items)-1]).String()
+		}
+
+		// There are newer documents before current page
+		if pagination.Before != nil || pagination.Offset > 0 {
+			page.PrevCursor = cursor(items[0]).String()
+		}
+	}
+
+	return page
+}
+
+func cursorFilter(cursor domain.Cursor, timeField, operator string) bson.M {
+	return bson.M{
+		"$or": bson.A{
+			bson.M{
+				timeField: bson.M{operator: cursor.CreatedAt},
+			},
+			bson.M{
+				timeField: cursor.CreatedAt,
+				"_id":     bson.M{operator: cursor.ID},
+			},
+		},
+	}
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+)
+
+const (
+	localDirPerm  = 0o750
+	localFilePerm = 0o640
+)
+
+// LocalStorage keeps blobs in local filesystem directory
+type LocalStorage struct {
+	path string
+}
+
+func NewLocalStorage(config *config.Storage) (*LocalStorage, error) {
+	if err := os.MkdirAll(config.LocalPath, localDirPerm); err != nil {
+		return nil, err
+	}
+
+	return &LocalStorage{
+		path: config.LocalPath,
+	}, nil
+}
+
+func (l *LocalStorage) Put(_ context.Context, key string, reader io.Reader, _ int64, _ string) error {
+	path := l.keyPath(key)
+
+	// Writing to temporary file first, so partially written objects are never visible
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// Does nothing if file was renamed
+		_ = os.Remove(file.Name())
+	}()
+
+	if _, err := io.Copy(file, reader); err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	if err := file.Chmod(localFilePerm); err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func (l *LocalStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	file, err := os.Open(l.keyPath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (l *LocalStorage) Delete(_ context.Context, key string) error {
+	err := os.Remove(l.keyPath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (l *LocalStorage) PresignedURL(context.Context, string, string, time.Duration) (string, error) {
+	// Local files are served by application
+	return "", nil
+}
+
+func (l *LocalStorage) keyPath(key string) string {
+	// Keys must not escape storage directory
+	return filepath.Join(l.path, filepath.Base(key))
+}
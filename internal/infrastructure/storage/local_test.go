@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+	"github.com/undefined7887/harmony-backend/internal/domain"
+)
+
+func newTestLocalStorage(t *testing.T) (*LocalStorage, string) {
+	path := filepath.Join(t.TempDir(), "files")
+
+	storage, err := NewLocalStorage(&config.Storage{
+		Type:      TypeLocal,
+		LocalPath: path,
+	})
+	require.NoError(t, err)
+
+	return storage, path
+}
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	storage, path := newTestLocalStorage(t)
+
+	testStorageRoundTrip(t, storage, domain.ID())
+
+	// Temporary files are not left behind
+	entries, err := os.ReadDir(path)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestLocalStorageKeyEscape(t *testing.T) {
+	storage, path := newTestLocalStorage(t)
+
+	content := []byte("content")
+	require.NoError(t, storage.Put(context.Background(), "../escaped", bytes.NewReader(content), int64(len(content)), ""))
+
+	_, err := os.Stat(filepath.Join(filepath.Dir(path), "escaped"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	data, err := os.ReadFile(filepath.Join(path, "escaped"))
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+}
+
+func TestLocalStoragePresignedURL(t *testing.T) {
+	storage, _ := newTestLocalStorage(t)
+
+	url, err := storage.PresignedURL(context.Background(), domain.ID(), "file", 0)
+	require.NoError(t, err)
+	require.Empty(t, url)
+}
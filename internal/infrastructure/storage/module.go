@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+)
+
+var Module = fx.Options(
+	fx.Provide(NewStorage),
+	fx.Invoke(NewStorageRunner),
+)
+
+func NewStorageRunner(lifecycle fx.Lifecycle, config *config.Storage, logger *zap.Logger, storage Storage) {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("initializing storage", zap.String("type", config.Type))
+
+			if s3Storage, ok := storage.(*S3Storage); ok {
+				return s3Storage.Init(ctx)
+			}
+
+			return nil
+		},
+	})
+}
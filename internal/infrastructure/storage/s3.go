@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+)
+
+// S3Storage keeps blobs in S3 compatible storage, for example MinIO
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3Storage(config *config.Storage) (*S3Storage, error) {
+	client, err := minio.New(config.S3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.S3AccessKey, config.S3SecretKey, ""),
+		Secure: config.S3Secure,
+		Region: config.S3Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3: %v", err)
+	}
+
+	return &S3Storage{
+		client: client,
+		bucket: config.S3Bucket,
+	}, nil
+}
+
+// Init creates bucket if it doesn't exist
+func (s *S3Storage) Init(ctx context.Context) error {
+	exists, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		return fmt.Errorf("s3: %v", err)
+	}
+
+	if exists {
+		return nil
+	}
+
+	if err := s.client.MakeBucket(ctx, s.bucket, minio.MakeBucketOptions{}); err != nil {
+		return fmt.Errorf("s3: %v", err)
+	}
+
+	return nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, reader, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+
+	return err
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	// Checking existence first, because GetObject is lazy
+	if _, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{}); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Storage) PresignedURL(ctx context.Context, key, name string, lifetime time.Duration) (string, error) {
+	params := url.Values{}
+	params.Set("response-content-disposition", ContentDisposition(name))
+
+	presignedURL, err := s.client.PresignedGetObject(ctx, s.bucket, key, lifetime, params)
+	if err != nil {
+		return "", err
+	}
+
+	return presignedURL.String(), nil
+}
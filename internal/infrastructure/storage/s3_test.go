@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+	"github.com/undefined7887/harmony-backend/internal/domain"
+)
+
+// Tests are skipped if MinIO address is not set, for example:
+//
+//	HARMONY_TEST_S3_ENDPOINT=127.0.0.1:9000 go test ./internal/infrastructure/storage
+//
+// Credentials are taken from the same variables as in deploy/local.docker-compose.yml
+const (
+	testS3EndpointVariable  = "HARMONY_TEST_S3_ENDPOINT"
+	testS3AccessKeyVariable = "HARMONY_S3_ACCESS_KEY"
+	testS3SecretKeyVariable = "HARMONY_S3_SECRET_KEY"
+)
+
+func newTestS3Storage(t *testing.T) *S3Storage {
+	endpoint := os.Getenv(testS3EndpointVariable)
+	if endpoint == "" {
+		t.Skipf("%s is not set", testS3EndpointVariable)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	storage, err := NewS3Storage(&config.Storage{
+		Type:        TypeS3,
+		S3Endpoint:  endpoint,
+		S3Bucket:    "harmony-test-" + strconv.FormatInt(time.Now().UnixNano(), 10),
+		S3AccessKey: os.Getenv(testS3AccessKeyVariable),
+		S3SecretKey: os.Getenv(testS3SecretKeyVariable),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, storage.Init(ctx))
+
+	// Bucket already exists
+	require.NoError(t, storage.Init(ctx))
+
+	t.Cleanup(func() {
+		// Objects are deleted by tests
+		_ = storage.client.RemoveBucket(context.Background(), storage.bucket)
+	})
+
+	return storage
+}
+
+func TestS3StorageRoundTrip(t *testing.T) {
+	storage := newTestS3Storage(t)
+
+	testStorageRoundTrip(t, storage, domain.ID())
+}
+
+func TestS3StoragePresignedURL(t *testing.T) {
+	storage := newTestS3Storage(t)
+
+	url, err := storage.PresignedURL(context.Background(), domain.ID(), "photo.png", time.Minute)
+	require.NoError(t, err)
+	require.True(t, strings.Contains(url, storage.bucket))
+}
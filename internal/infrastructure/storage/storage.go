@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+)
+
+const (
+	TypeLocal = "local"
+	TypeS3    = "s3"
+)
+
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage keeps blobs by keys
+type Storage interface {
+	Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error
+
+	// Get returns ErrNotFound if there is no object with provided key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	Delete(ctx context.Context, key string) error
+
+	// PresignedURL returns url for direct downloading from storage.
+	// Empty string is returned if storage doesn't support direct downloading
+	PresignedURL(ctx context.Context, key, name string, lifetime time.Duration) (string, error)
+}
+
+func NewStorage(config *config.Storage) (Storage, error) {
+	switch config.Type {
+	case TypeLocal:
+		return NewLocalStorage(config)
+
+	case TypeS3:
+		return NewS3Storage(config)
+
+	default:
+		return nil, fmt.Errorf("storage: unknown type \"%s\"", config.Type)
+	}
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testStorageRoundTrip checks behaviour, which is common for all storage implementations
+func testStorageRoundTrip(t *testing.T, storage Storage, key string) {
+	ctx := context.Background()
+	content := []byte("harmony storage test content")
+
+	_, err := storage.Get(ctx, key)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, storage.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "text/plain"))
+
+	reader, err := storage.Get(ctx, key)
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	require.Equal(t, content, data)
+
+	// Objects are overwritten by key
+	updated := []byte("updated")
+	require.NoError(t, storage.Put(ctx, key, bytes.NewReader(updated), int64(len(updated)), "text/plain"))
+
+	reader, err = storage.Get(ctx, key)
+	require.NoError(t, err)
+
+	data, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	require.Equal(t, updated, data)
+
+	require.NoError(t, storage.Delete(ctx, key))
+
+	_, err = storage.Get(ctx, key)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// Deleting missing object is not an error
+	require.NoError(t, storage.Delete(ctx, key))
+}
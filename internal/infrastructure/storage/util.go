@@ -0,0 +1,10 @@
+package storage
+
+import "mime"
+
+// ContentDisposition returns value of Content-Disposition header for downloading file with provided name
+func ContentDisposition(name string) string {
+	return mime.FormatMediaType("attachment", map[string]string{
+		"filename": name,
+	})
+}
@@ -2,17 +2,16 @@ package callrepo
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"github.com/undefined7887/harmony-backend/internal/domain"
 	calldomain "github.com/undefined7887/harmony-backend/internal/domain/call"
 	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
-	"github.com/undefined7887/harmony-backend/internal/util"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/fx"
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
+	"time"
 )
 
 const (
@@ -52,6 +51,19 @@ func NewMongoMigrationsRunner(lifecycle fx.Lifecycle, logger *zap.Logger, databa
 					BuildIndex(ctx,
 						mongodatabase.IndexKeys("status"),
 					),
+
+				// Used by history pagination
+				mongodatabase.
+					NewQuery[any](database.Collection(callCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeysCustom(-1, "user_id", "created_at", "_id"),
+					),
+
+				mongodatabase.
+					NewQuery[any](database.Collection(callCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeysCustom(-1, "peer_id", "created_at", "_id"),
+					),
 			)
 		},
 	})
@@ -86,6 +98,14 @@ func (m *MongoRepository) Create(ctx context.Context, call *calldomain.Call) (bo
 	return result.UpsertedCount > 0, nil
 }
 
+func (m *MongoRepository) Get(ctx context.Context, id string) (calldomain.Call, error) {
+	return mongodatabase.
+		NewQuery[calldomain.Call](m.database.Collection(callCollection)).
+		FindOne(ctx, bson.M{
+			"_id": id,
+		})
+}
+
 func (m *MongoRepository) Read(ctx context.Context, id, status string) (calldomain.Call, error) {
 	return mongodatabase.
 		NewQuery[calldomain.Call](m.database.Collection(callCollection)).
@@ -107,31 +127,73 @@ func (m *MongoRepository) ReadLast(ctx context.Context, userID, status string) (
 		})
 }
 
-func (m *MongoRepository) UpdateStatus(ctx context.Context, id, peerID string, previousStatuses []string, newStatus string) (calldomain.Call, error) {
-	match := bson.M{
-		"_id": id,
-		"status": bson.M{
-			// Converting to []any
-			"$in": util.Map(previousStatuses, func(item string) any {
-				return item
-			}),
+func (m *MongoRepository) ListByStatus(ctx context.Context, status string, createdBefore time.Time) ([]calldomain.Call, error) {
+	return mongodatabase.
+		NewQuery[calldomain.Call](m.database.Collection(callCollection)).
+		Find(ctx, bson.M{
+			"status":     status,
+			"created_at": bson.M{"$lt": createdBefore},
+		})
+}
+
+func (m *MongoRepository) List(
+	ctx context.Context,
+	userID, peerID string,
+	pagination domain.Pagination,
+) (domain.Page[calldomain.Call], error) {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"user_id": userID},
+			bson.M{"peer_id": userID},
 		},
 	}
 
 	if peerID != "" {
-		match["peer_id"] = peerID
+		filter["$or"] = bson.A{
+			bson.M{"user_id": userID, "peer_id": peerID},
+			bson.M{"user_id": peerID, "peer_id": userID},
+		}
 	}
 
-	res, _ := json.Marshal(match)
-	fmt.Println(string(res))
+	pipeline := append(
+		bson.A{
+			bson.M{
+				"$match": filter,
+			},
+		},
+		mongodatabase.PaginationStages(pagination, "created_at")...,
+	)
+
+	calls, err := mongodatabase.
+		NewQuery[calldomain.Call](m.database.Collection(callCollection)).
+		Aggregate(ctx, pipeline)
 
+	if err != nil {
+		return domain.Page[calldomain.Call]{}, err
+	}
+
+	return mongodatabase.NewPage(calls, pagination, func(call calldomain.Call) domain.Cursor {
+		return domain.Cursor{
+			CreatedAt: call.CreatedAt,
+			ID:        call.ID,
+		}
+	}), nil
+}
+
+func (m *MongoRepository) UpdateStatus(
+	ctx context.Context,
+	id, previousStatus string,
+	update calldomain.StatusUpdate,
+) (calldomain.Call, error) {
 	return mongodatabase.NewQuery[calldomain.Call](m.database.Collection(callCollection)).
 		FindOneAndUpdate(ctx,
-			match,
+			// Transition is checked for previous status, so concurrent changes are rejected
 			bson.M{
-				"$set": bson.M{
-					"status": newStatus,
-				},
+				"_id":    id,
+				"status": previousStatus,
+			},
+			bson.M{
+				"$set": update,
 			},
 			options.
 				FindOneAndUpdate().
@@ -3,10 +3,42 @@ package chatrepo
 import "go.uber.org/fx"
 
 var Module = fx.Options(
+	RepositoryModule,
+
+	// Chat lists must be built before serving requests
+	fx.Invoke(NewMongoChatMemberCheckRunner),
+)
+
+// RepositoryModule provides repositories without startup checks, used by maintenance commands
+var RepositoryModule = fx.Options(
 	// Message repository
 	fx.Provide(NewMongoMessageRepository),
 	fx.Invoke(NewMongoMessageMigrationsRunner),
 
+	// Message search repository
+	fx.Provide(NewMongoMessageSearchRepository),
+
 	// Chat repository
 	fx.Provide(NewMongoChatRepository),
+	fx.Invoke(NewMongoChatMigrationsRunner),
+	fx.Invoke(NewMongoChatMemberMigrationsRunner),
+
+	// Group repository
+	fx.Provide(NewMongoGroupRepository),
+	fx.Invoke(NewMongoGroupMigrationsRunner),
+
+	// Folder repository
+	fx.Provide(NewMongoFolderRepository),
+	fx.Invoke(NewMongoFolderMigrationsRunner),
+
+	// Scheduled message repository
+	fx.Provide(NewMongoScheduledMessageRepository),
+	fx.Invoke(NewMongoScheduledMessageMigrationsRunner),
+
+	// Draft repository
+	fx.Provide(NewMongoDraftRepository),
+
+	// Pin repository
+	fx.Provide(NewMongoPinRepository),
+	fx.Invoke(NewMongoPinMigrationsRunner),
 )
@@ -2,13 +2,31 @@ package chatrepo
 
 import (
 	"context"
+	"errors"
+	"strconv"
 	"time"
 
+	"github.com/samber/lo"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
 
+	"github.com/undefined7887/harmony-backend/internal/domain"
 	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
 	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+	"github.com/undefined7887/harmony-backend/internal/util"
+)
+
+const (
+	readStateCollection = "chat_reads"
+	ttlCollection       = "chat_ttls"
+
+	// For more information, see:
+	// https://www.mongodb.com/docs/manual/reference/error-codes
+	indexNotFoundCode = 27
 )
 
 type MongoChatRepository struct {
@@ -21,41 +39,221 @@ func NewMongoChatRepository(db *mongo.Database) chatdomain.ChatRepository {
 	}
 }
 
-func (m *MongoChatRepository) List(ctx context.Context, userID, peerType string, offset, limit int64) ([]chatdomain.Chat, error) {
-	match := bson.M{
-		"$or": bson.A{
-			bson.M{"user_id": userID},
-			bson.M{"peer_id": userID},
+func NewMongoChatMigrationsRunner(lifecycle fx.Lifecycle, logger *zap.Logger, database *mongo.Database) {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("running migrations", zap.String("collection", readStateCollection))
+
+			if err := mongodatabase.
+				NewQuery[any](database.Collection(readStateCollection)).
+				BuildIndex(ctx,
+					mongodatabase.IndexKeys("chat_id", "message_created_at"),
+				); err != nil {
+				return err
+			}
+
+			return backfillReadStates(ctx, logger, database)
 		},
+	})
+}
+
+// backfillReadStates converts legacy read_user_ids arrays of messages into read states.
+// Converted arrays are removed, so next runs do nothing
+func backfillReadStates(ctx context.Context, logger *zap.Logger, database *mongo.Database) error {
+	messages := database.Collection(messageCollection)
+
+	filter := bson.M{
+		"read_user_ids": bson.M{"$exists": true},
+	}
+
+	exists, err := mongodatabase.
+		NewQuery[any](messages).
+		Exists(ctx, filter)
+
+	if err != nil || !exists {
+		return err
+	}
+
+	logger.Info("backfilling read states from messages")
+
+	if _, err := mongodatabase.
+		NewQuery[any](messages).
+		Aggregate(ctx,
+			bson.A{
+				bson.M{
+					"$match": bson.M{
+						"read_user_ids.0": bson.M{"$exists": true},
+					},
+				},
+				bson.M{
+					"$sort": bson.M{
+						"created_at": 1,
+					},
+				},
+				bson.M{
+					"$unwind": "$read_user_ids",
+				},
+				bson.M{
+					// Latest read message of every user in every chat
+					"$group": bson.M{
+						"_id": bson.M{
+							"$concat": bson.A{"$chat_id", ":", "$read_user_ids"},
+						},
+						"chat_id":            bson.M{"$last": "$chat_id"},
+						"user_id":            bson.M{"$last": "$read_user_ids"},
+						"message_id":         bson.M{"$last": "$_id"},
+						"message_created_at": bson.M{"$last": "$created_at"},
+						"updated_at":         bson.M{"$last": "$updated_at"},
+					},
+				},
+				bson.M{
+					"$merge": bson.M{
+						"into":           readStateCollection,
+						"on":             "_id",
+						"whenMatched":    "keepExisting",
+						"whenNotMatched": "insert",
+					},
+				},
+			},
+			options.
+				Aggregate().
+				SetAllowDiskUse(true),
+		); err != nil {
+		return err
+	}
+
+	if _, err := messages.UpdateMany(ctx, filter, bson.M{
+		"$unset": bson.M{
+			"read_user_ids": "",
+			"reads":         "",
+		},
+	}); err != nil {
+		return err
+	}
+
+	// Index of legacy arrays is not used anymore
+	_, err = messages.Indexes().DropOne(ctx, "read_user_ids_1")
+
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Code == indexNotFoundCode {
+		return nil
+	}
+
+	return err
+}
+
+func (m *MongoChatRepository) List(
+	ctx context.Context,
+	userID string,
+	filter chatdomain.ChatFilter,
+	pagination domain.Pagination,
+) (domain.Page[chatdomain.Chat], error) {
+	match := chatMatch(userID, filter)
+
+	// Pinned chats are listed separately
+	match["pin_order"] = bson.M{"$not": bson.M{"$gt": 0}}
+
+	// Chat members are ordered by last message, so pagination uses index
+	chats, err := m.list(ctx, match, mongodatabase.PaginationStages(pagination, "message_created_at"))
+	if err != nil {
+		return domain.Page[chatdomain.Chat]{}, err
+	}
+
+	return mongodatabase.NewPage(chats, pagination, func(chat chatdomain.Chat) domain.Cursor {
+		// Chat id differs from chat member id, which is used for ordering
+		return domain.Cursor{
+			CreatedAt: chat.Message.CreatedAt,
+			ID:        chatdomain.ChatMemberID(chat.Message.ChatID, userID),
+		}
+	}), nil
+}
+
+func (m *MongoChatRepository) ListPinned(
+	ctx context.Context,
+	userID string,
+	filter chatdomain.ChatFilter,
+) ([]chatdomain.Chat, error) {
+	match := chatMatch(userID, filter)
+	match["pin_order"] = bson.M{"$gt": 0}
+
+	// Number of pinned chats is limited, so they are not paginated
+	return m.list(ctx, match, bson.A{
+		bson.M{
+			"$sort": bson.D{
+				{Key: "pin_order", Value: 1},
+				{Key: "message_created_at", Value: -1},
+			},
+		},
+	})
+}
+
+// chatMatch returns filter of chat members, which are shown in chat list of user
+func chatMatch(userID string, filter chatdomain.ChatFilter) bson.M {
+	match := bson.M{
+		"user_id": userID,
+
+		// Chats without messages are not shown
+		"message_id": bson.M{"$exists": true},
 	}
 
 	// Add filter on chat_type if presented
-	if peerType != "" {
-		match["peer_type"] = peerType
+	if filter.PeerType != "" {
+		match["peer_type"] = filter.PeerType
+	}
+
+	// Settings fields may be absent, so negative filters are built with $ne and $not
+	if filter.Archived != nil {
+		if *filter.Archived {
+			match["archived"] = true
+		} else {
+			match["archived"] = bson.M{"$ne": true}
+		}
+	}
+
+	if filter.Muted != nil {
+		if *filter.Muted {
+			match["muted_until"] = bson.M{"$gt": time.Now()}
+		} else {
+			match["muted_until"] = bson.M{"$not": bson.M{"$gt": time.Now()}}
+		}
 	}
 
+	if filter.Folder != nil {
+		match = lo.Assign(match, folderMatch(*filter.Folder))
+	}
+
+	return match
+}
+
+// list selects chat members by filter and joins them with messages, groups and pins
+func (m *MongoChatRepository) list(ctx context.Context, match bson.M, stages bson.A) ([]chatdomain.Chat, error) {
 	pipeline := bson.A{
 		bson.M{
 			"$match": match,
 		},
+	}
+
+	pipeline = append(pipeline, stages...)
+
+	pipeline = append(pipeline,
 		bson.M{
-			"$sort": bson.M{
-				"created_at": -1,
+			"$lookup": bson.M{
+				"from": messageCollection,
+				"as":   "message",
+
+				"localField":   "message_id",
+				"foreignField": "_id",
 			},
 		},
 		bson.M{
-			"$replaceRoot": bson.M{
-				"newRoot": bson.M{
-					"message": "$$ROOT",
-				},
-			},
+			"$unwind": "$message",
 		},
 		bson.M{
 			"$lookup": bson.M{
 				"from": groupCollection,
 				"as":   "group",
 
-				"localField":   "message.chat_id",
+				"localField":   "chat_id",
 				"foreignField": "_id",
 			},
 		},
@@ -69,41 +267,70 @@ func (m *MongoChatRepository) List(ctx context.Context, userID, peerType string,
 			},
 		},
 		bson.M{
-			"$group": bson.M{
-				"_id": "$message.chat_id",
-				"group": bson.M{
-					"$first": "$group",
-				},
-				"message": bson.M{
-					"$first": "$message",
+			// Draft of current user
+			"$lookup": bson.M{
+				"from": draftCollection,
+				"as":   "draft",
+
+				"localField":   "_id",
+				"foreignField": "_id",
+			},
+		},
+		bson.M{
+			"$unwind": bson.M{
+				"path":                       "$draft",
+				"preserveNullAndEmptyArrays": true,
+			},
+		},
+		bson.M{
+			// Latest pinned message of chat
+			"$lookup": bson.M{
+				"from": pinCollection,
+				"as":   "pinned_message",
+
+				"let": bson.M{
+					"chat_id": "$chat_id",
 				},
-				"unread_count": bson.M{
-					"$sum": bson.M{
-						"$cond": bson.M{
-							// Do not count if:
-							// - current user is sender
-							// or
-							// - current user read message
-							"if": bson.M{
-								"$or": bson.A{
-									bson.M{
-										"$eq": bson.A{userID, "$message.user_id"},
-									},
-									bson.M{
-										"$setIsSubset": bson.A{bson.A{userID}, "$message.read_user_ids"},
-									},
-								},
+				"pipeline": bson.A{
+					bson.M{
+						"$match": bson.M{
+							"$expr": bson.M{
+								"$eq": bson.A{"$chat_id", "$$chat_id"},
 							},
-							"then": 0,
-							"else": 1,
+						},
+					},
+					bson.M{
+						"$sort": bson.M{
+							"created_at": -1,
+						},
+					},
+					bson.M{
+						"$limit": 1,
+					},
+					bson.M{
+						"$lookup": bson.M{
+							"from": messageCollection,
+							"as":   "message",
+
+							"localField":   "message_id",
+							"foreignField": "_id",
+						},
+					},
+					bson.M{
+						"$unwind": "$message",
+					},
+					bson.M{
+						"$replaceRoot": bson.M{
+							"newRoot": "$message",
 						},
 					},
 				},
 			},
 		},
 		bson.M{
-			"$sort": bson.M{
-				"message.created_at": -1,
+			"$unwind": bson.M{
+				"path":                       "$pinned_message",
+				"preserveNullAndEmptyArrays": true,
 			},
 		},
 		bson.M{
@@ -111,22 +338,17 @@ func (m *MongoChatRepository) List(ctx context.Context, userID, peerType string,
 				"chat": bson.M{
 					"$mergeObjects": bson.A{
 						bson.M{
-							"message":      "$message",
-							"unread_count": "$unread_count",
-						},
-						// User chat
-						bson.M{
-							// For user chats choosing chat_id as opposite to current user
-							"_id": bson.M{
-								"$cond": bson.M{
-									"if": bson.M{
-										"$eq": bson.A{userID, "$message.user_id"},
-									},
-									"then": "$message.peer_id",
-									"else": "$message.user_id",
-								},
-							},
-							"type": "$message.peer_type",
+							// For user chats peer is opposite to current user
+							"_id":                   "$peer_id",
+							"type":                  "$peer_type",
+							"message":               "$message",
+							"pinned_message":        "$pinned_message",
+							"draft":                 "$draft",
+							"unread_count":          "$unread_count",
+							"unread_mentions_count": "$unread_mentions_count",
+							"muted_until":           "$muted_until",
+							"archived":              "$archived",
+							"pin_order":             "$pin_order",
 						},
 
 						// Group chat
@@ -140,53 +362,368 @@ func (m *MongoChatRepository) List(ctx context.Context, userID, peerType string,
 				"newRoot": "$chat",
 			},
 		},
+	)
+
+	return mongodatabase.
+		NewQuery[chatdomain.Chat](m.database.Collection(chatMemberCollection)).
+		Aggregate(ctx, pipeline)
+}
+
+func (m *MongoChatRepository) CountFoldersUnread(
+	ctx context.Context,
+	userID string,
+	filters []chatdomain.FolderFilter,
+) ([]chatdomain.FolderUnread, error) {
+	if len(filters) == 0 {
+		return nil, nil
 	}
 
-	if offset > 0 {
-		pipeline = append(pipeline, bson.M{
-			"$skip": offset,
-		})
+	// Every folder is counted in its own facet, so chat members are scanned once
+	facets := bson.M{}
+
+	for i, filter := range filters {
+		facets[folderFacet(i)] = bson.A{
+			bson.M{
+				"$match": folderMatch(filter),
+			},
+			bson.M{
+				"$group": bson.M{
+					"_id": nil,
+					"unread_chats_count": bson.M{
+						"$sum": bson.M{
+							"$cond": bson.M{
+								"if":   bson.M{"$gt": bson.A{"$unread_count", 0}},
+								"then": 1,
+								"else": 0,
+							},
+						},
+					},
+					"unread_count": bson.M{
+						"$sum": "$unread_count",
+					},
+					"unread_mentions_count": bson.M{
+						"$sum": "$unread_mentions_count",
+					},
+				},
+			},
+		}
 	}
 
-	if limit > 0 {
-		pipeline = append(pipeline, bson.M{
-			"$limit": limit,
+	results, err := mongodatabase.
+		NewQuery[map[string][]chatdomain.FolderUnread](m.database.Collection(chatMemberCollection)).
+		Aggregate(ctx, bson.A{
+			bson.M{
+				"$match": bson.M{
+					"user_id":    userID,
+					"message_id": bson.M{"$exists": true},
+				},
+			},
+			bson.M{
+				"$facet": facets,
+			},
 		})
+
+	if err != nil {
+		return nil, err
+	}
+
+	unread := make([]chatdomain.FolderUnread, len(filters))
+
+	for i := range filters {
+		// Facet is empty if folder has no chats
+		if len(results) > 0 && len(results[0][folderFacet(i)]) > 0 {
+			unread[i] = results[0][folderFacet(i)][0]
+		}
+	}
+
+	return unread, nil
+}
+
+func folderFacet(index int) string {
+	return "folder_" + strconv.Itoa(index)
+}
+
+// folderMatch matches chat members, which satisfy any rule of folder
+func folderMatch(filter chatdomain.FolderFilter) bson.M {
+	var rules bson.A
+
+	if len(filter.PeerIDs) > 0 {
+		rules = append(rules, bson.M{"peer_id": bson.M{"$in": filter.PeerIDs}})
+	}
+
+	if len(filter.PeerTypes) > 0 {
+		rules = append(rules, bson.M{"peer_type": bson.M{"$in": filter.PeerTypes}})
+	}
+
+	if filter.Unread {
+		rules = append(rules, bson.M{"unread_count": bson.M{"$gt": 0}})
 	}
 
+	// Folder without rules is empty
+	if len(rules) == 0 {
+		return bson.M{"_id": bson.M{"$in": bson.A{}}}
+	}
+
+	return bson.M{"$or": rules}
+}
+
+func (m *MongoChatRepository) GetTTL(ctx context.Context, chatID string) (chatdomain.ChatTTL, error) {
 	return mongodatabase.
-		NewQuery[chatdomain.Chat](m.database.Collection(messageCollection)).
-		Aggregate(ctx, pipeline)
+		NewQuery[chatdomain.ChatTTL](m.database.Collection(ttlCollection)).
+		FindOne(ctx, bson.M{
+			"_id": chatID,
+		})
 }
 
-func (m *MongoChatRepository) UpdateRead(ctx context.Context, userID, chatID string) (int64, error) {
-	result, err := m.database.
-		Collection(messageCollection).
-		UpdateMany(ctx,
+func (m *MongoChatRepository) UpdateTTL(ctx context.Context, ttl chatdomain.ChatTTL) (chatdomain.ChatTTL, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.ChatTTL](m.database.Collection(ttlCollection)).
+		FindOneAndUpdate(ctx,
 			bson.M{
-				"chat_id": chatID,
+				"_id": ttl.ID,
+			},
+			bson.M{
+				"$set": bson.M{
+					"ttl":        ttl.TTL,
+					"user_id":    ttl.UserID,
+					"updated_at": ttl.UpdatedAt,
+				},
+			},
+			options.
+				FindOneAndUpdate().
+				SetUpsert(true).
+				SetReturnDocument(options.After),
+		)
+}
 
-				// User can't read self messages
-				"user_id": bson.M{"$ne": userID},
+func (m *MongoChatRepository) GetMember(ctx context.Context, chatID, userID string) (chatdomain.ChatMember, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.ChatMember](m.database.Collection(chatMemberCollection)).
+		FindOne(ctx, bson.M{
+			"_id": chatdomain.ChatMemberID(chatID, userID),
+		})
+}
 
-				// User can't re-read message
-				"read_user_ids": bson.M{
-					"$nin": bson.A{userID},
-				},
+func (m *MongoChatRepository) UpdateSettings(
+	ctx context.Context,
+	member chatdomain.ChatMember,
+	pinnedLimit int64,
+) (chatdomain.ChatMember, error) {
+	// Settings without pinning don't need limit check
+	if member.PinOrder == 0 {
+		return m.updateSettings(ctx, member)
+	}
+
+	return mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (chatdomain.ChatMember, error) {
+		// Concurrent pinning of user chats can't exceed limit
+		if err := mongodatabase.Lock(ctx, m.database, chatMemberCollection+":pinned:"+member.UserID); err != nil {
+			return chatdomain.ChatMember{}, err
+		}
+
+		// Order of already pinned chat can be changed regardless of limit
+		pinned, err := mongodatabase.
+			NewQuery[any](m.database.Collection(chatMemberCollection)).
+			Exists(ctx, bson.M{
+				"_id":       chatdomain.ChatMemberID(member.ChatID, member.UserID),
+				"pin_order": bson.M{"$gt": 0},
+			})
+
+		if err != nil {
+			return chatdomain.ChatMember{}, err
+		}
+
+		if !pinned {
+			count, err := m.countPinned(ctx, member.UserID)
+			if err != nil {
+				return chatdomain.ChatMember{}, err
+			}
+
+			if count >= pinnedLimit {
+				return chatdomain.ChatMember{}, repository.ErrLimitExceeded
+			}
+		}
+
+		return m.updateSettings(ctx, member)
+	})
+}
+
+func (m *MongoChatRepository) updateSettings(ctx context.Context, member chatdomain.ChatMember) (chatdomain.ChatMember, error) {
+	set := bson.M{
+		"archived":  member.Archived,
+		"pin_order": member.PinOrder,
+	}
+
+	update := bson.M{
+		"$set": set,
+
+		// User chats may have no entry before the first message
+		"$setOnInsert": bson.M{
+			"chat_id":               member.ChatID,
+			"user_id":               member.UserID,
+			"peer_id":               member.PeerID,
+			"peer_type":             member.PeerType,
+			"unread_count":          0,
+			"unread_mentions_count": 0,
+		},
+	}
+
+	if member.MutedUntil != nil {
+		set["muted_until"] = member.MutedUntil
+	} else {
+		update["$unset"] = bson.M{
+			"muted_until": "",
+		}
+	}
+
+	return mongodatabase.
+		NewQuery[chatdomain.ChatMember](m.database.Collection(chatMemberCollection)).
+		FindOneAndUpdate(ctx,
+			bson.M{
+				"_id": chatdomain.ChatMemberID(member.ChatID, member.UserID),
 			},
+			update,
+			options.
+				FindOneAndUpdate().
+				SetUpsert(true).
+				SetReturnDocument(options.After),
+		)
+}
+
+func (m *MongoChatRepository) countPinned(ctx context.Context, userID string) (int64, error) {
+	return m.database.
+		Collection(chatMemberCollection).
+		CountDocuments(ctx, bson.M{
+			"user_id":   userID,
+			"pin_order": bson.M{"$gt": 0},
+		})
+}
+
+func (m *MongoChatRepository) ListMutedUserIDs(ctx context.Context, chatID string, now time.Time) ([]string, error) {
+	members, err := mongodatabase.
+		NewQuery[chatdomain.ChatMember](m.database.Collection(chatMemberCollection)).
+		Find(ctx,
 			bson.M{
-				"$addToSet": bson.M{
-					"read_user_ids": userID,
-				},
-				"$set": bson.M{
-					"updated_at": time.Now(),
+				"chat_id":     chatID,
+				"muted_until": bson.M{"$gt": now},
+			},
+			options.
+				Find().
+				SetProjection(bson.M{"user_id": 1}),
+		)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Map(members, func(member chatdomain.ChatMember) string {
+		return member.UserID
+	}), nil
+}
+
+func (m *MongoChatRepository) UpdateRead(ctx context.Context, state chatdomain.ReadState) (bool, error) {
+	return mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (bool, error) {
+		updated, err := m.updateReadState(ctx, state)
+		if err != nil || !updated {
+			return false, err
+		}
+
+		message, err := mongodatabase.
+			NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+			FindOne(ctx, bson.M{
+				"_id": state.MessageID,
+			})
+
+		if err != nil {
+			return false, err
+		}
+
+		// Unread counters are recalculated from new watermark
+		if err := refreshMessageChatMember(ctx, m.database, message, state.UserID); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	})
+}
+
+func (m *MongoChatRepository) updateReadState(ctx context.Context, state chatdomain.ReadState) (bool, error) {
+	result, err := m.database.
+		Collection(readStateCollection).
+		UpdateOne(ctx,
+			bson.M{
+				"_id": chatdomain.ReadStateID(state.ChatID, state.UserID),
+			},
+			// Watermark can only move forward
+			bson.A{
+				bson.M{
+					"$set": bson.M{
+						"chat_id": state.ChatID,
+						"user_id": state.UserID,
+						"message_id": bson.M{
+							"$cond": bson.M{
+								"if":   bson.M{"$lt": bson.A{"$message_created_at", state.MessageCreatedAt}},
+								"then": state.MessageID,
+								"else": "$message_id",
+							},
+						},
+						"message_created_at": bson.M{
+							"$max": bson.A{"$message_created_at", state.MessageCreatedAt},
+						},
+						"updated_at": bson.M{
+							"$cond": bson.M{
+								"if":   bson.M{"$lt": bson.A{"$message_created_at", state.MessageCreatedAt}},
+								"then": state.UpdatedAt,
+								"else": "$updated_at",
+							},
+						},
+					},
 				},
 			},
+			options.
+				Update().
+				SetUpsert(true),
 		)
 
 	if err != nil {
-		return 0, err
+		return false, err
 	}
 
-	return result.ModifiedCount, nil
+	return result.ModifiedCount > 0 || result.UpsertedCount > 0, nil
+}
+
+func (m *MongoChatRepository) ListReads(ctx context.Context, chatID string, sentAt time.Time) ([]chatdomain.ReadState, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.ReadState](m.database.Collection(readStateCollection)).
+		Find(ctx, bson.M{
+			"chat_id":            chatID,
+			"message_created_at": bson.M{"$gte": sentAt},
+		})
+}
+
+func (m *MongoChatRepository) ListReadSummaries(
+	ctx context.Context,
+	userID string,
+	chatIDs []string,
+) ([]chatdomain.ReadSummary, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.ReadSummary](m.database.Collection(readStateCollection)).
+		Aggregate(ctx, bson.A{
+			bson.M{
+				"$match": bson.M{
+					"chat_id": bson.M{"$in": chatIDs},
+					"user_id": bson.M{"$ne": userID},
+				},
+			},
+			// Only the latest watermark is returned instead of states of all participants
+			bson.M{
+				"$group": bson.M{
+					"_id":                "$chat_id",
+					"message_created_at": bson.M{"$max": "$message_created_at"},
+				},
+			},
+		})
+}
+
+func (m *MongoChatRepository) Rebuild(ctx context.Context, progress func(count int64)) error {
+	return rebuildChatMembers(ctx, m.database, progress)
 }
@@ -0,0 +1,525 @@
+package chatrepo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/fx"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+	"github.com/undefined7887/harmony-backend/internal/util"
+)
+
+// Chat members are materialized chat lists of users.
+// They are updated by message and group repositories in the same transactions as messages and group members
+
+const (
+	chatMemberCollection = "chat_members"
+)
+
+func NewMongoChatMemberMigrationsRunner(lifecycle fx.Lifecycle, logger *zap.Logger, database *mongo.Database) {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("running migrations", zap.String("collection", chatMemberCollection))
+
+			return multierr.Combine(
+				// Used by chat list
+				mongodatabase.
+					NewQuery[any](database.Collection(chatMemberCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeysCustom(-1, "user_id", "message_created_at", "_id"),
+					),
+
+				mongodatabase.
+					NewQuery[any](database.Collection(chatMemberCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("chat_id", "user_id"),
+					),
+			)
+		},
+	})
+}
+
+// NewMongoChatMemberCheckRunner stops startup if chat lists were never built for existing chats,
+// for example after upgrade from version without chat_members collection
+func NewMongoChatMemberCheckRunner(lifecycle fx.Lifecycle, logger *zap.Logger, database *mongo.Database) {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("checking chat lists", zap.String("collection", chatMemberCollection))
+
+			return checkChatMembers(ctx, database)
+		},
+	})
+}
+
+func checkChatMembers(ctx context.Context, database *mongo.Database) error {
+	built, err := mongodatabase.
+		NewQuery[any](database.Collection(chatMemberCollection)).
+		Exists(ctx, bson.M{})
+
+	if err != nil || built {
+		return err
+	}
+
+	// Both user and group chats appear in chat lists
+	for _, collection := range []string{messageCollection, groupMemberCollection} {
+		exists, err := mongodatabase.
+			NewQuery[any](database.Collection(collection)).
+			Exists(ctx, bson.M{})
+
+		if err != nil {
+			return err
+		}
+
+		// Rebuilding can take long time, so it's not done on startup
+		if exists {
+			return fmt.Errorf(
+				"%s collection is empty, but %s collection is not: run rebuild_chats command to build chat lists",
+				chatMemberCollection,
+				collection,
+			)
+		}
+	}
+
+	return nil
+}
+
+// addChatMessage moves message to the top of participants chat lists and updates unread counters
+func addChatMessage(ctx context.Context, database *mongo.Database, message chatdomain.Message) error {
+	collection := database.Collection(chatMemberCollection)
+
+	set := bson.M{
+		"message_id":         message.ID,
+		"message_created_at": message.CreatedAt,
+	}
+
+	// User chats appear in chat lists with the first message
+	if message.PeerType == chatdomain.PeerTypeUser {
+		for _, userID := range lo.Uniq([]string{message.UserID, message.PeerID}) {
+			peerID := message.PeerID
+			if userID == message.PeerID {
+				peerID = message.UserID
+			}
+
+			inc := bson.M{
+				"unread_count":          0,
+				"unread_mentions_count": 0,
+			}
+
+			if userID != message.UserID {
+				inc["unread_count"] = 1
+
+				if lo.Contains(message.MentionUserIDs, userID) {
+					inc["unread_mentions_count"] = 1
+				}
+			}
+
+			if _, err := collection.UpdateOne(ctx,
+				bson.M{
+					"_id": chatdomain.ChatMemberID(message.ChatID, userID),
+				},
+				bson.M{
+					"$set": lo.Assign(set, bson.M{
+						"chat_id":   message.ChatID,
+						"user_id":   userID,
+						"peer_id":   peerID,
+						"peer_type": message.PeerType,
+					}),
+					"$inc": inc,
+				},
+				options.
+					Update().
+					SetUpsert(true),
+			); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	// Group members have chat list entries since joining
+	if _, err := collection.UpdateMany(ctx,
+		bson.M{
+			"chat_id": message.ChatID,
+		},
+		bson.M{
+			"$set": set,
+		},
+	); err != nil {
+		return err
+	}
+
+	if _, err := collection.UpdateMany(ctx,
+		bson.M{
+			"chat_id": message.ChatID,
+			"user_id": bson.M{"$ne": message.UserID},
+		},
+		bson.M{
+			"$inc": bson.M{
+				"unread_count": 1,
+			},
+		},
+	); err != nil {
+		return err
+	}
+
+	return incChatMentions(ctx, database, message.ChatID, message.MentionUserIDs, 1)
+}
+
+// removeUnreadMessage decrements unread counters of users, who haven't read message yet
+func removeUnreadMessage(ctx context.Context, database *mongo.Database, message chatdomain.Message) error {
+	states, err := mongodatabase.
+		NewQuery[chatdomain.ReadState](database.Collection(readStateCollection)).
+		Find(ctx, bson.M{
+			"chat_id":            message.ChatID,
+			"message_created_at": bson.M{"$gte": message.CreatedAt},
+		})
+
+	if err != nil {
+		return err
+	}
+
+	// Sender, users who read message and users who deleted message for themselves don't count it
+	excludedUserIDs := append(util.Map(states, func(state chatdomain.ReadState) string {
+		return state.UserID
+	}), message.UserID)
+
+	excludedUserIDs = append(excludedUserIDs, message.DeletedUserIDs...)
+
+	if _, err := database.
+		Collection(chatMemberCollection).
+		UpdateMany(ctx,
+			bson.M{
+				"chat_id":      message.ChatID,
+				"user_id":      bson.M{"$nin": excludedUserIDs},
+				"unread_count": bson.M{"$gt": 0},
+			},
+			bson.M{
+				"$inc": bson.M{
+					"unread_count": -1,
+				},
+			},
+		); err != nil {
+		return err
+	}
+
+	return incChatMentions(ctx, database, message.ChatID, lo.Without(message.MentionUserIDs, excludedUserIDs...), -1)
+}
+
+func incChatMentions(ctx context.Context, database *mongo.Database, chatID string, userIDs []string, value int) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	filter := bson.M{
+		"chat_id": chatID,
+		"user_id": bson.M{"$in": userIDs},
+	}
+
+	// Counter can't be negative
+	if value < 0 {
+		filter["unread_mentions_count"] = bson.M{"$gt": 0}
+	}
+
+	_, err := database.
+		Collection(chatMemberCollection).
+		UpdateMany(ctx, filter, bson.M{
+			"$inc": bson.M{
+				"unread_mentions_count": value,
+			},
+		})
+
+	return err
+}
+
+// refreshChatMember recalculates chat list entry of user from messages and read state
+func refreshChatMember(ctx context.Context, database *mongo.Database, member chatdomain.ChatMember) error {
+	messages := database.Collection(messageCollection)
+
+	visibleFilter := bson.M{
+		"chat_id": member.ChatID,
+
+		// Thread messages are not shown in chat
+		"thread_root_id": bson.M{"$exists": false},
+
+		// Skipping messages deleted by user
+		"deleted_user_ids": bson.M{"$ne": member.UserID},
+	}
+
+	lastMessage, err := mongodatabase.
+		NewQuery[chatdomain.Message](messages).
+		FindOne(ctx,
+			visibleFilter,
+			options.
+				FindOne().
+				SetSort(bson.D{
+					{Key: "created_at", Value: -1},
+					{Key: "_id", Value: -1},
+				}),
+		)
+
+	hasMessage := err == nil
+
+	if err != nil && !repository.IsNoDocumentsErr(err) {
+		return err
+	}
+
+	state, err := mongodatabase.
+		NewQuery[chatdomain.ReadState](database.Collection(readStateCollection)).
+		FindOne(ctx, bson.M{
+			"_id": chatdomain.ReadStateID(member.ChatID, member.UserID),
+		})
+
+	if err != nil && !repository.IsNoDocumentsErr(err) {
+		return err
+	}
+
+	unreadFilter := lo.Assign(visibleFilter, bson.M{
+		"created_at": bson.M{"$gt": state.MessageCreatedAt},
+		"user_id":    bson.M{"$ne": member.UserID},
+		"deleted":    bson.M{"$ne": true},
+	})
+
+	unreadCount, err := messages.CountDocuments(ctx, unreadFilter)
+	if err != nil {
+		return err
+	}
+
+	unreadMentionsCount, err := messages.CountDocuments(ctx, lo.Assign(unreadFilter, bson.M{
+		"mention_user_ids": member.UserID,
+	}))
+
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"chat_id":               member.ChatID,
+			"user_id":               member.UserID,
+			"peer_id":               member.PeerID,
+			"peer_type":             member.PeerType,
+			"unread_count":          unreadCount,
+			"unread_mentions_count": unreadMentionsCount,
+		},
+	}
+
+	if hasMessage {
+		update["$set"].(bson.M)["message_id"] = lastMessage.ID
+		update["$set"].(bson.M)["message_created_at"] = lastMessage.CreatedAt
+	} else {
+		update["$unset"] = bson.M{
+			"message_id":         "",
+			"message_created_at": "",
+		}
+	}
+
+	_, err = database.
+		Collection(chatMemberCollection).
+		UpdateOne(ctx,
+			bson.M{
+				"_id": chatdomain.ChatMemberID(member.ChatID, member.UserID),
+			},
+			update,
+			options.
+				Update().
+				SetUpsert(true),
+		)
+
+	return err
+}
+
+// refreshMessageChatMember refreshes chat list entry of user in message chat
+func refreshMessageChatMember(ctx context.Context, database *mongo.Database, message chatdomain.Message, userID string) error {
+	// Thread messages don't affect chat list
+	if message.ThreadRootID != "" {
+		return nil
+	}
+
+	peerID := message.PeerID
+	if message.PeerType == chatdomain.PeerTypeUser && userID == message.PeerID {
+		peerID = message.UserID
+	}
+
+	return refreshChatMember(ctx, database, chatdomain.ChatMember{
+		ChatID:   message.ChatID,
+		UserID:   userID,
+		PeerID:   peerID,
+		PeerType: message.PeerType,
+	})
+}
+
+// refreshGroupChatMember refreshes chat list entry of group member
+func refreshGroupChatMember(ctx context.Context, database *mongo.Database, member chatdomain.GroupMember) error {
+	return refreshChatMember(ctx, database, chatdomain.ChatMember{
+		ChatID:   member.GroupID,
+		UserID:   member.UserID,
+		PeerID:   member.GroupID,
+		PeerType: chatdomain.PeerTypeGroup,
+	})
+}
+
+func deleteChatMember(ctx context.Context, database *mongo.Database, chatID, userID string) error {
+	_, err := database.
+		Collection(chatMemberCollection).
+		DeleteOne(ctx, bson.M{
+			"_id": chatdomain.ChatMemberID(chatID, userID),
+		})
+
+	return err
+}
+
+// rebuildChatMembers regenerates chat lists of all users from messages and group members
+func rebuildChatMembers(ctx context.Context, database *mongo.Database, progress func(count int64)) error {
+	var count int64
+
+	refresh := func(member chatdomain.ChatMember) error {
+		if err := refreshChatMember(ctx, database, member); err != nil {
+			return err
+		}
+
+		count++
+		progress(count)
+
+		return nil
+	}
+
+	// Participants of user chats are taken from any chat message
+	userChats, err := mongodatabase.
+		NewQuery[chatdomain.Message](database.Collection(messageCollection)).
+		Aggregate(ctx,
+			bson.A{
+				bson.M{
+					"$match": bson.M{
+						"peer_type": chatdomain.PeerTypeUser,
+					},
+				},
+				bson.M{
+					"$group": bson.M{
+						"_id":       "$chat_id",
+						"chat_id":   bson.M{"$first": "$chat_id"},
+						"user_id":   bson.M{"$first": "$user_id"},
+						"peer_id":   bson.M{"$first": "$peer_id"},
+						"peer_type": bson.M{"$first": "$peer_type"},
+					},
+				},
+			},
+			options.
+				Aggregate().
+				SetAllowDiskUse(true),
+		)
+
+	if err != nil {
+		return err
+	}
+
+	for _, chat := range userChats {
+		for _, userID := range lo.Uniq([]string{chat.UserID, chat.PeerID}) {
+			peerID := chat.PeerID
+			if userID == chat.PeerID {
+				peerID = chat.UserID
+			}
+
+			if err := refresh(chatdomain.ChatMember{
+				ChatID:   chat.ChatID,
+				UserID:   userID,
+				PeerID:   peerID,
+				PeerType: chat.PeerType,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := mongodatabase.
+		NewQuery[chatdomain.GroupMember](database.Collection(groupMemberCollection)).
+		FindCursor(ctx,
+			bson.M{},
+			func(ctx context.Context, members []chatdomain.GroupMember) error {
+				for _, member := range members {
+					if err := refreshGroupChatMember(ctx, database, member); err != nil {
+						return err
+					}
+
+					count++
+					progress(count)
+				}
+
+				return nil
+			},
+		); err != nil {
+		return err
+	}
+
+	return deleteStaleChatMembers(ctx, database)
+}
+
+// deleteStaleChatMembers removes group chat list entries of users, who are not group members anymore.
+// Entries are not recreated from scratch during rebuild, so chat settings are preserved
+func deleteStaleChatMembers(ctx context.Context, database *mongo.Database) error {
+	staleMembers, err := mongodatabase.
+		NewQuery[chatdomain.ChatMember](database.Collection(chatMemberCollection)).
+		Aggregate(ctx, bson.A{
+			bson.M{
+				"$match": bson.M{
+					"peer_type": chatdomain.PeerTypeGroup,
+				},
+			},
+			bson.M{
+				"$lookup": bson.M{
+					"from": groupMemberCollection,
+					"as":   "group_member",
+
+					"let": bson.M{
+						"group_id": "$chat_id",
+						"user_id":  "$user_id",
+					},
+					"pipeline": bson.A{
+						bson.M{
+							"$match": bson.M{
+								"$expr": bson.M{
+									"$and": bson.A{
+										bson.M{"$eq": bson.A{"$group_id", "$$group_id"}},
+										bson.M{"$eq": bson.A{"$user_id", "$$user_id"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			bson.M{
+				"$match": bson.M{
+					"group_member": bson.M{"$size": 0},
+				},
+			},
+			bson.M{
+				"$project": bson.M{
+					"_id": 1,
+				},
+			},
+		})
+
+	if err != nil || len(staleMembers) == 0 {
+		return err
+	}
+
+	_, err = database.
+		Collection(chatMemberCollection).
+		DeleteMany(ctx, bson.M{
+			"_id": bson.M{"$in": util.Map(staleMembers, func(member chatdomain.ChatMember) string {
+				return member.ID
+			})},
+		})
+
+	return err
+}
@@ -0,0 +1,222 @@
+package chatrepo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
+)
+
+// Benchmarks require running mongo, for example from deploy/local.docker-compose.yml:
+//
+//	HARMONY_BENCH_MONGO_ADDRESS=127.0.0.1:27017 go test -run=^$ -bench=ListChats ./internal/repository/chat
+const benchMongoAddressVariable = "HARMONY_BENCH_MONGO_ADDRESS"
+
+const (
+	benchChats           = 50
+	benchMessagesPerChat = 2000
+
+	// Part of messages, which are read by benchmark user
+	benchReadRatio = 0.9
+)
+
+// BenchmarkListChats measures chat list materialized in chat members
+func BenchmarkListChats(b *testing.B) {
+	database, userID := newBenchDatabase(b)
+	repository := NewMongoChatRepository(database)
+
+	pagination := domain.Pagination{Limit: domain.PaginationDefaultLimit}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := repository.List(context.Background(), userID, chatdomain.ChatFilter{}, pagination); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkListChatsLegacy measures previous chat list, which counted unread messages with read_user_ids arrays
+func BenchmarkListChatsLegacy(b *testing.B) {
+	database, userID := newBenchDatabase(b)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := mongodatabase.
+			NewQuery[chatdomain.Chat](database.Collection(messageCollection)).
+			Aggregate(context.Background(), legacyListPipeline(userID)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func legacyListPipeline(userID string) bson.A {
+	return bson.A{
+		bson.M{
+			"$match": bson.M{
+				"$or": bson.A{
+					bson.M{"peer_type": chatdomain.PeerTypeUser, "user_id": userID},
+					bson.M{"peer_type": chatdomain.PeerTypeUser, "peer_id": userID},
+				},
+				"thread_root_id":   bson.M{"$exists": false},
+				"deleted_user_ids": bson.M{"$ne": userID},
+			},
+		},
+		bson.M{
+			"$sort": bson.M{
+				"created_at": -1,
+			},
+		},
+		bson.M{
+			"$group": bson.M{
+				"_id": "$chat_id",
+				"message": bson.M{
+					"$first": "$$ROOT",
+				},
+				"unread_count": bson.M{
+					"$sum": bson.M{
+						"$cond": bson.M{
+							"if": bson.M{
+								"$or": bson.A{
+									bson.M{"$eq": bson.A{userID, "$user_id"}},
+									bson.M{"$eq": bson.A{true, "$deleted"}},
+									bson.M{"$setIsSubset": bson.A{bson.A{userID}, "$read_user_ids"}},
+								},
+							},
+							"then": 0,
+							"else": 1,
+						},
+					},
+				},
+			},
+		},
+		bson.M{
+			"$sort": bson.M{
+				"message.created_at": -1,
+			},
+		},
+		bson.M{
+			"$limit": domain.PaginationDefaultLimit,
+		},
+	}
+}
+
+// newBenchDatabase creates temporary database with user chats, which have both
+// legacy read_user_ids arrays and read states, so both implementations see the same data.
+// Chat members are rebuilt from created messages
+func newBenchDatabase(b *testing.B) (*mongo.Database, string) {
+	address := os.Getenv(benchMongoAddressVariable)
+	if address == "" {
+		b.Skipf("%s is not set", benchMongoAddressVariable)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+	defer cancel()
+
+	database, err := mongodatabase.NewDatabase(&config.Mongo{
+		Address:  address,
+		Direct:   true,
+		Database: "harmony_bench_" + time.Now().Format("20060102150405"),
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	if err := database.Client().Connect(ctx); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Cleanup(func() {
+		_ = database.Drop(context.Background())
+		_ = database.Client().Disconnect(context.Background())
+	})
+
+	if err := mongodatabase.
+		NewQuery[any](database.Collection(messageCollection)).
+		BuildIndex(ctx,
+			mongodatabase.IndexKeysCustom(-1, "chat_id", "created_at", "_id"),
+		); err != nil {
+		b.Fatal(err)
+	}
+
+	userID := domain.ID()
+	start := time.Now().Add(-time.Hour)
+
+	for i := 0; i < benchChats; i++ {
+		var (
+			peerID   = domain.ID()
+			chatID   = chatdomain.ChatID(userID, peerID, chatdomain.PeerTypeUser)
+			messages = make([]any, 0, benchMessagesPerChat)
+			state    chatdomain.ReadState
+		)
+
+		for j := 0; j < benchMessagesPerChat; j++ {
+			createdAt := start.Add(time.Duration(i*benchMessagesPerChat+j) * time.Millisecond)
+
+			message := chatdomain.Message{
+				ID:        domain.ID(),
+				UserID:    peerID,
+				PeerID:    userID,
+				PeerType:  chatdomain.PeerTypeUser,
+				ChatID:    chatID,
+				Text:      "benchmark message",
+				CreatedAt: createdAt,
+				UpdatedAt: createdAt,
+			}
+
+			read := j < int(benchMessagesPerChat*benchReadRatio)
+			if read {
+				state = chatdomain.ReadState{
+					ID:               chatdomain.ReadStateID(chatID, userID),
+					ChatID:           chatID,
+					UserID:           userID,
+					MessageID:        message.ID,
+					MessageCreatedAt: message.CreatedAt,
+					UpdatedAt:        message.CreatedAt,
+				}
+			}
+
+			messages = append(messages, legacyMessage{
+				Message:     message,
+				ReadUserIDs: readUserIDs(userID, read),
+			})
+		}
+
+		if _, err := database.Collection(messageCollection).InsertMany(ctx, messages); err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := database.Collection(readStateCollection).InsertOne(ctx, state); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	if err := NewMongoChatRepository(database).Rebuild(ctx, func(int64) {}); err != nil {
+		b.Fatal(err)
+	}
+
+	return database, userID
+}
+
+type legacyMessage struct {
+	chatdomain.Message `bson:",inline"`
+
+	ReadUserIDs []string `bson:"read_user_ids"`
+}
+
+func readUserIDs(userID string, read bool) []string {
+	if read {
+		return []string{userID}
+	}
+
+	return []string{}
+}
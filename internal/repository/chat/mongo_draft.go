@@ -0,0 +1,74 @@
+package chatrepo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
+)
+
+// Drafts have the same ids as chat members, so they are joined to chat list by _id
+const (
+	draftCollection = "chat_drafts"
+)
+
+type MongoDraftRepository struct {
+	database *mongo.Database
+}
+
+func NewMongoDraftRepository(database *mongo.Database) chatdomain.DraftRepository {
+	return &MongoDraftRepository{
+		database: database,
+	}
+}
+
+func (m *MongoDraftRepository) Update(ctx context.Context, draft chatdomain.Draft) (chatdomain.Draft, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.Draft](m.database.Collection(draftCollection)).
+		FindOneAndUpdate(ctx,
+			bson.M{
+				"_id": chatdomain.DraftID(draft.ChatID, draft.UserID),
+			},
+			bson.M{
+				"$set": bson.M{
+					"chat_id":     draft.ChatID,
+					"user_id":     draft.UserID,
+					"peer_id":     draft.PeerID,
+					"peer_type":   draft.PeerType,
+					"text":        draft.Text,
+					"reply_to_id": draft.ReplyToID,
+					"updated_at":  draft.UpdatedAt,
+				},
+			},
+			options.
+				FindOneAndUpdate().
+				SetUpsert(true).
+				SetReturnDocument(options.After),
+		)
+}
+
+func (m *MongoDraftRepository) Get(ctx context.Context, chatID, userID string) (chatdomain.Draft, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.Draft](m.database.Collection(draftCollection)).
+		FindOne(ctx, bson.M{
+			"_id": chatdomain.DraftID(chatID, userID),
+		})
+}
+
+func (m *MongoDraftRepository) Delete(ctx context.Context, chatID, userID string) (bool, error) {
+	result, err := m.database.
+		Collection(draftCollection).
+		DeleteOne(ctx, bson.M{
+			"_id": chatdomain.DraftID(chatID, userID),
+		})
+
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
@@ -0,0 +1,136 @@
+package chatrepo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+)
+
+const (
+	folderCollection = "chat_folders"
+)
+
+type MongoFolderRepository struct {
+	database *mongo.Database
+}
+
+func NewMongoFolderRepository(database *mongo.Database) chatdomain.FolderRepository {
+	return &MongoFolderRepository{
+		database: database,
+	}
+}
+
+func NewMongoFolderMigrationsRunner(lifecycle fx.Lifecycle, logger *zap.Logger, database *mongo.Database) {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("running migrations", zap.String("collection", folderCollection))
+
+			return mongodatabase.
+				NewQuery[any](database.Collection(folderCollection)).
+				BuildIndex(ctx,
+					mongodatabase.IndexKeys("user_id", "created_at"),
+				)
+		},
+	})
+}
+
+func (m *MongoFolderRepository) Create(ctx context.Context, folder *chatdomain.Folder, limit int64) (bool, error) {
+	return mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (bool, error) {
+		// Concurrent creation of user folders can't exceed limit
+		if err := mongodatabase.Lock(ctx, m.database, folderCollection+":"+folder.UserID); err != nil {
+			return false, err
+		}
+
+		count, err := m.count(ctx, folder.UserID)
+		if err != nil {
+			return false, err
+		}
+
+		if count >= limit {
+			return false, repository.ErrLimitExceeded
+		}
+
+		return mongodatabase.
+			NewQuery[chatdomain.Folder](m.database.Collection(folderCollection)).
+			InsertOne(ctx, folder)
+	})
+}
+
+func (m *MongoFolderRepository) Get(ctx context.Context, id, userID string) (chatdomain.Folder, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.Folder](m.database.Collection(folderCollection)).
+		FindOne(ctx, bson.M{
+			"_id": id,
+
+			// Folders are private
+			"user_id": userID,
+		})
+}
+
+func (m *MongoFolderRepository) List(ctx context.Context, userID string) ([]chatdomain.Folder, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.Folder](m.database.Collection(folderCollection)).
+		Find(ctx,
+			bson.M{
+				"user_id": userID,
+			},
+			options.
+				Find().
+				SetSort(bson.M{"created_at": 1}),
+		)
+}
+
+func (m *MongoFolderRepository) count(ctx context.Context, userID string) (int64, error) {
+	return m.database.
+		Collection(folderCollection).
+		CountDocuments(ctx, bson.M{
+			"user_id": userID,
+		})
+}
+
+func (m *MongoFolderRepository) Update(ctx context.Context, folder chatdomain.Folder) (chatdomain.Folder, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.Folder](m.database.Collection(folderCollection)).
+		FindOneAndUpdate(ctx,
+			bson.M{
+				"_id":     folder.ID,
+				"user_id": folder.UserID,
+			},
+			bson.M{
+				"$set": bson.M{
+					"name":       folder.Name,
+					"chats":      folder.Chats,
+					"peer_types": folder.PeerTypes,
+					"unread":     folder.Unread,
+					"user_ids":   folder.UserIDs,
+					"updated_at": folder.UpdatedAt,
+				},
+			},
+			options.
+				FindOneAndUpdate().
+				SetReturnDocument(options.After),
+		)
+}
+
+func (m *MongoFolderRepository) Delete(ctx context.Context, id, userID string) (bool, error) {
+	result, err := m.database.
+		Collection(folderCollection).
+		DeleteOne(ctx, bson.M{
+			"_id":     id,
+			"user_id": userID,
+		})
+
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
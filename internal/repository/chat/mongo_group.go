@@ -1,5 +1,409 @@
 package chatrepo
 
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/fx"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
+	"github.com/undefined7887/harmony-backend/internal/util"
+)
+
 const (
-	groupCollection = "groups"
+	groupCollection       = "groups"
+	groupMemberCollection = "group_members"
+	groupInviteCollection = "group_invites"
 )
+
+// Used to abort transactions, because failed insert of existing member aborts them anyway
+var errGroupMemberExists = errors.New("group member already exists")
+
+type MongoGroupRepository struct {
+	database *mongo.Database
+}
+
+func NewMongoGroupRepository(database *mongo.Database) chatdomain.GroupRepository {
+	return &MongoGroupRepository{
+		database: database,
+	}
+}
+
+func NewMongoGroupMigrationsRunner(lifecycle fx.Lifecycle, logger *zap.Logger, database *mongo.Database) {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("running migrations", zap.String("collection", groupMemberCollection))
+
+			return multierr.Combine(
+				mongodatabase.
+					NewQuery[any](database.Collection(groupMemberCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("group_id", "user_id"),
+						options.
+							Index().
+							SetUnique(true),
+					),
+
+				mongodatabase.
+					NewQuery[any](database.Collection(groupMemberCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("user_id"),
+					),
+
+				mongodatabase.
+					NewQuery[any](database.Collection(groupInviteCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("group_id"),
+					),
+
+				// Expired invites are removed automatically
+				mongodatabase.
+					NewQuery[any](database.Collection(groupInviteCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("expire_at"),
+						options.
+							Index().
+							SetExpireAfterSeconds(0),
+					),
+			)
+		},
+	})
+}
+
+func (m *MongoGroupRepository) Create(ctx context.Context, group *chatdomain.Group, members []chatdomain.GroupMember) (bool, error) {
+	return mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (bool, error) {
+		inserted, err := mongodatabase.
+			NewQuery[chatdomain.Group](m.database.Collection(groupCollection)).
+			InsertOne(ctx, group)
+
+		if err != nil || !inserted {
+			return false, err
+		}
+
+		_, err = m.database.
+			Collection(groupMemberCollection).
+			InsertMany(ctx, util.Map(members, func(member chatdomain.GroupMember) any {
+				return member
+			}))
+
+		if err != nil {
+			return false, err
+		}
+
+		for _, member := range members {
+			if err := refreshGroupChatMember(ctx, m.database, member); err != nil {
+				return false, err
+			}
+		}
+
+		return true, nil
+	})
+}
+
+func (m *MongoGroupRepository) Get(ctx context.Context, id string) (chatdomain.Group, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.Group](m.database.Collection(groupCollection)).
+		FindOne(ctx, bson.M{
+			"_id": id,
+		})
+}
+
+func (m *MongoGroupRepository) UpdateName(ctx context.Context, id, name string) (chatdomain.Group, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.Group](m.database.Collection(groupCollection)).
+		FindOneAndUpdate(ctx,
+			bson.M{
+				"_id": id,
+			},
+			bson.M{
+				"$set": bson.M{
+					"name":       name,
+					"updated_at": time.Now(),
+				},
+			},
+			options.
+				FindOneAndUpdate().
+				SetReturnDocument(options.After),
+		)
+}
+
+func (m *MongoGroupRepository) AddMember(ctx context.Context, member *chatdomain.GroupMember) (bool, error) {
+	added, err := mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (bool, error) {
+		inserted, err := mongodatabase.
+			NewQuery[chatdomain.GroupMember](m.database.Collection(groupMemberCollection)).
+			InsertOne(ctx, member)
+
+		if err != nil {
+			return false, err
+		}
+
+		if !inserted {
+			return false, errGroupMemberExists
+		}
+
+		if err := refreshGroupChatMember(ctx, m.database, *member); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	})
+
+	if errors.Is(err, errGroupMemberExists) {
+		return false, nil
+	}
+
+	return added, err
+}
+
+func (m *MongoGroupRepository) GetMember(ctx context.Context, groupID, userID string) (chatdomain.GroupMember, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.GroupMember](m.database.Collection(groupMemberCollection)).
+		FindOne(ctx, bson.M{
+			"group_id": groupID,
+			"user_id":  userID,
+		})
+}
+
+func (m *MongoGroupRepository) ListMembers(ctx context.Context, groupID string) ([]chatdomain.GroupMember, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.GroupMember](m.database.Collection(groupMemberCollection)).
+		Find(ctx,
+			bson.M{
+				"group_id": groupID,
+			},
+			options.
+				Find().
+				SetSort(bson.M{"created_at": 1}),
+		)
+}
+
+func (m *MongoGroupRepository) ListUserGroupIDs(ctx context.Context, userID string) ([]string, error) {
+	members, err := mongodatabase.
+		NewQuery[chatdomain.GroupMember](m.database.Collection(groupMemberCollection)).
+		Find(ctx,
+			bson.M{
+				"user_id": userID,
+			},
+			options.
+				Find().
+				SetProjection(bson.M{"group_id": 1}),
+		)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Map(members, func(member chatdomain.GroupMember) string {
+		return member.GroupID
+	}), nil
+}
+
+func (m *MongoGroupRepository) UpdateMemberRole(ctx context.Context, groupID, userID, role string) (chatdomain.GroupMember, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.GroupMember](m.database.Collection(groupMemberCollection)).
+		FindOneAndUpdate(ctx,
+			bson.M{
+				"group_id": groupID,
+				"user_id":  userID,
+
+				// Owner can be changed only by ownership transfer
+				"role": bson.M{"$ne": chatdomain.GroupRoleOwner},
+			},
+			bson.M{
+				"$set": bson.M{
+					"role":       role,
+					"updated_at": time.Now(),
+				},
+			},
+			options.
+				FindOneAndUpdate().
+				SetReturnDocument(options.After),
+		)
+}
+
+func (m *MongoGroupRepository) TransferOwnership(ctx context.Context, groupID, ownerID, newOwnerID string) (bool, error) {
+	collection := m.database.Collection(groupMemberCollection)
+
+	return mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (bool, error) {
+		now := time.Now()
+
+		result, err := collection.UpdateOne(ctx,
+			bson.M{
+				"group_id": groupID,
+				"user_id":  ownerID,
+				"role":     chatdomain.GroupRoleOwner,
+			},
+			bson.M{
+				"$set": bson.M{
+					"role":       chatdomain.GroupRoleAdmin,
+					"updated_at": now,
+				},
+			},
+		)
+
+		if err != nil || result.ModifiedCount == 0 {
+			return false, err
+		}
+
+		result, err = collection.UpdateOne(ctx,
+			bson.M{
+				"group_id": groupID,
+				"user_id":  newOwnerID,
+			},
+			bson.M{
+				"$set": bson.M{
+					"role":       chatdomain.GroupRoleOwner,
+					"updated_at": now,
+				},
+			},
+		)
+
+		if err != nil {
+			return false, err
+		}
+
+		if result.MatchedCount == 0 {
+			// Aborting transaction, so previous owner keeps owner role
+			return false, mongo.ErrNoDocuments
+		}
+
+		return true, nil
+	})
+}
+
+func (m *MongoGroupRepository) DeleteMember(ctx context.Context, groupID, userID string) (bool, error) {
+	return mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (bool, error) {
+		result, err := m.database.
+			Collection(groupMemberCollection).
+			DeleteOne(ctx, bson.M{
+				"group_id": groupID,
+				"user_id":  userID,
+
+				// Owner must transfer ownership before leaving
+				"role": bson.M{"$ne": chatdomain.GroupRoleOwner},
+			})
+
+		if err != nil || result.DeletedCount == 0 {
+			return false, err
+		}
+
+		// Group disappears from chat list of user
+		if err := deleteChatMember(ctx, m.database, groupID, userID); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	})
+}
+
+func (m *MongoGroupRepository) CreateInvite(ctx context.Context, invite *chatdomain.GroupInvite) (bool, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.GroupInvite](m.database.Collection(groupInviteCollection)).
+		InsertOne(ctx, invite)
+}
+
+func (m *MongoGroupRepository) GetInvite(ctx context.Context, id string) (chatdomain.GroupInvite, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.GroupInvite](m.database.Collection(groupInviteCollection)).
+		FindOne(ctx, bson.M{
+			"_id": id,
+
+			// TTL index removes documents with delay
+			"expire_at": bson.M{"$gt": time.Now()},
+		})
+}
+
+func (m *MongoGroupRepository) ListInvites(ctx context.Context, groupID string) ([]chatdomain.GroupInvite, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.GroupInvite](m.database.Collection(groupInviteCollection)).
+		Find(ctx,
+			bson.M{
+				"group_id":  groupID,
+				"expire_at": bson.M{"$gt": time.Now()},
+			},
+			options.
+				Find().
+				SetSort(bson.M{"created_at": -1}),
+		)
+}
+
+func (m *MongoGroupRepository) Join(ctx context.Context, inviteID string, member *chatdomain.GroupMember) (bool, error) {
+	joined, err := mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (bool, error) {
+		if _, err := m.useInvite(ctx, inviteID); err != nil {
+			return false, err
+		}
+
+		inserted, err := mongodatabase.
+			NewQuery[chatdomain.GroupMember](m.database.Collection(groupMemberCollection)).
+			InsertOne(ctx, member)
+
+		if err != nil {
+			return false, err
+		}
+
+		if !inserted {
+			// Aborting transaction, so invite use is not wasted
+			return false, errGroupMemberExists
+		}
+
+		if err := refreshGroupChatMember(ctx, m.database, *member); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	})
+
+	if errors.Is(err, errGroupMemberExists) {
+		return false, nil
+	}
+
+	return joined, err
+}
+
+// useInvite increments uses count of non-expired invite if limit is not reached
+func (m *MongoGroupRepository) useInvite(ctx context.Context, id string) (chatdomain.GroupInvite, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.GroupInvite](m.database.Collection(groupInviteCollection)).
+		FindOneAndUpdate(ctx,
+			bson.M{
+				"_id":       id,
+				"expire_at": bson.M{"$gt": time.Now()},
+
+				// Checking uses limit
+				"$or": bson.A{
+					bson.M{"max_uses": 0},
+					bson.M{"$expr": bson.M{"$lt": bson.A{"$uses", "$max_uses"}}},
+				},
+			},
+			bson.M{
+				"$inc": bson.M{
+					"uses": 1,
+				},
+			},
+			options.
+				FindOneAndUpdate().
+				SetReturnDocument(options.After),
+		)
+}
+
+func (m *MongoGroupRepository) DeleteInvite(ctx context.Context, groupID, id string) (bool, error) {
+	result, err := m.database.
+		Collection(groupInviteCollection).
+		DeleteOne(ctx, bson.M{
+			"_id":      id,
+			"group_id": groupID,
+		})
+
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
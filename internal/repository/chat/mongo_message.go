@@ -2,8 +2,10 @@ package chatrepo
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/samber/lo"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -11,14 +13,22 @@ import (
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
 
+	"github.com/undefined7887/harmony-backend/internal/domain"
 	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
 	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+	"github.com/undefined7887/harmony-backend/internal/util"
 )
 
 const (
-	messageCollection = "messages"
+	messageCollection         = "messages"
+	messageRevisionCollection = "message_revisions"
+	fileCleanupCollection     = "message_file_cleanups"
 )
 
+// Used to abort create transaction, because failed insert of existing message aborts it anyway
+var errMessageExists = errors.New("message already exists")
+
 type MongoMessageRepository struct {
 	database *mongo.Database
 }
@@ -41,16 +51,79 @@ func NewMongoMessageMigrationsRunner(lifecycle fx.Lifecycle, logger *zap.Logger,
 						mongodatabase.IndexKeys("chat_id"),
 					),
 
+				// Used by cursor pagination
+				mongodatabase.
+					NewQuery[any](database.Collection(messageCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeysCustom(-1, "chat_id", "created_at", "_id"),
+					),
+
 				mongodatabase.
 					NewQuery[any](database.Collection(messageCollection)).
 					BuildIndex(ctx,
 						mongodatabase.IndexKeys("user_id", "peer_id"),
 					),
 
+				// Used by MongoMessageSearchRepository
 				mongodatabase.
 					NewQuery[any](database.Collection(messageCollection)).
 					BuildIndex(ctx,
-						mongodatabase.IndexKeys("read_user_ids"),
+						mongodatabase.IndexKeysCustom("text", "text"),
+						options.
+							Index().
+							// Messages are written in different languages, so stemming is disabled
+							SetDefaultLanguage("none"),
+					),
+
+				mongodatabase.
+					NewQuery[any](database.Collection(messageCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("thread_root_id"),
+						options.
+							Index().
+							SetSparse(true),
+					),
+
+				mongodatabase.
+					NewQuery[any](database.Collection(messageCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("reply_to._id"),
+						options.
+							Index().
+							SetSparse(true),
+					),
+
+				// Used by reaper of disappearing messages.
+				// It's not a TTL index, because expired messages require cleanup and notifications
+				mongodatabase.
+					NewQuery[any](database.Collection(messageCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("expire_at"),
+						options.
+							Index().
+							SetSparse(true),
+					),
+
+				// Used to update attachments after file processing
+				mongodatabase.
+					NewQuery[any](database.Collection(messageCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("attachments._id"),
+						options.
+							Index().
+							SetSparse(true),
+					),
+
+				mongodatabase.
+					NewQuery[any](database.Collection(messageRevisionCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("message_id", "replaced_at"),
+					),
+
+				mongodatabase.
+					NewQuery[any](database.Collection(pollVoteCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("message_id", "created_at"),
 					),
 			)
 		},
@@ -58,9 +131,50 @@ func NewMongoMessageMigrationsRunner(lifecycle fx.Lifecycle, logger *zap.Logger,
 }
 
 func (m *MongoMessageRepository) Create(ctx context.Context, message *chatdomain.Message) (bool, error) {
-	return mongodatabase.
-		NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
-		InsertOne(ctx, message)
+	attachmentIDs := util.Map(message.Attachments, func(attachment chatdomain.Attachment) string {
+		return attachment.ID
+	})
+
+	// Thread messages are not shown in chat lists, so only their attachments need transaction
+	if message.ThreadRootID != "" && len(attachmentIDs) == 0 {
+		return mongodatabase.
+			NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+			InsertOne(ctx, message)
+	}
+
+	created, err := mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (bool, error) {
+		if err := lockFiles(ctx, m.database, attachmentIDs); err != nil {
+			return false, err
+		}
+
+		inserted, err := mongodatabase.
+			NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+			InsertOne(ctx, message)
+
+		if err != nil {
+			return false, err
+		}
+
+		if !inserted {
+			return false, errMessageExists
+		}
+
+		if message.ThreadRootID != "" {
+			return true, nil
+		}
+
+		if err := addChatMessage(ctx, m.database, *message); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	})
+
+	if errors.Is(err, errMessageExists) {
+		return false, nil
+	}
+
+	return created, err
 }
 
 func (m *MongoMessageRepository) Get(ctx context.Context, id string) (chatdomain.Message, error) {
@@ -71,48 +185,667 @@ func (m *MongoMessageRepository) Get(ctx context.Context, id string) (chatdomain
 		})
 }
 
-func (m *MongoMessageRepository) List(ctx context.Context, chatID string, offset, limit int64) ([]chatdomain.Message, error) {
-	listOptions := options.
-		Find().
-		SetSort(bson.M{"created_at": -1})
+func (m *MongoMessageRepository) ListByIDs(ctx context.Context, ids []string) ([]chatdomain.Message, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+		Find(ctx, bson.M{
+			"_id": bson.M{"$in": ids},
+		})
+}
+
+func (m *MongoMessageRepository) List(
+	ctx context.Context,
+	chatID, userID string,
+	pagination domain.Pagination,
+) (domain.Page[chatdomain.Message], error) {
+	return m.list(ctx,
+		bson.M{
+			"chat_id": chatID,
+
+			// Thread messages are not shown in chat
+			"thread_root_id": bson.M{"$exists": false},
+
+			// Skipping messages deleted by user
+			"deleted_user_ids": bson.M{"$ne": userID},
+		},
+		pagination,
+	)
+}
+
+func (m *MongoMessageRepository) ListThread(
+	ctx context.Context,
+	rootID, userID string,
+	pagination domain.Pagination,
+) (domain.Page[chatdomain.Message], error) {
+	return m.list(ctx,
+		bson.M{
+			"thread_root_id": rootID,
+
+			// Skipping messages deleted by user
+			"deleted_user_ids": bson.M{"$ne": userID},
+		},
+		pagination,
+	)
+}
 
-	if offset > 0 {
-		listOptions.SetSkip(offset)
+func (m *MongoMessageRepository) list(
+	ctx context.Context,
+	filter bson.M,
+	pagination domain.Pagination,
+) (domain.Page[chatdomain.Message], error) {
+	pipeline := append(
+		bson.A{
+			bson.M{
+				"$match": filter,
+			},
+		},
+		mongodatabase.PaginationStages(pagination, "created_at")...,
+	)
+
+	messages, err := mongodatabase.
+		NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+		Aggregate(ctx, pipeline)
+
+	if err != nil {
+		return domain.Page[chatdomain.Message]{}, err
 	}
 
-	if limit > 0 {
-		listOptions.SetLimit(limit)
+	return mongodatabase.NewPage(messages, pagination, messageCursor), nil
+}
+
+func (m *MongoMessageRepository) UpdateText(
+	ctx context.Context,
+	id, userID, text string,
+	mentionUserIDs []string,
+	sentAfter time.Time,
+) (chatdomain.Message, error) {
+	message, err := mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (chatdomain.Message, error) {
+		editedAt := time.Now()
+
+		previousMessage, err := mongodatabase.
+			NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+			FindOneAndUpdate(ctx,
+				bson.M{
+					"_id": id,
+
+					// Only sender can modify message
+					"user_id": userID,
+
+					// Deleted messages can't be modified
+					"deleted": bson.M{"$ne": true},
+
+					"created_at": bson.M{"$gte": sentAfter},
+				},
+				bson.M{
+					"$set": bson.M{
+						"text":             text,
+						"edited":           true,
+						"edited_at":        editedAt,
+						"mention_user_ids": mentionUserIDs,
+						"updated_at":       editedAt,
+					},
+				},
+				options.
+					FindOneAndUpdate().
+					SetReturnDocument(options.Before),
+			)
+
+		if err != nil {
+			return chatdomain.Message{}, err
+		}
+
+		revision := chatdomain.Revision{
+			ID:         domain.ID(),
+			MessageID:  previousMessage.ID,
+			Text:       previousMessage.Text,
+			CreatedAt:  lo.FromPtrOr(previousMessage.EditedAt, previousMessage.CreatedAt),
+			ReplacedAt: editedAt,
+		}
+
+		if _, err := mongodatabase.
+			NewQuery[chatdomain.Revision](m.database.Collection(messageRevisionCollection)).
+			InsertOne(ctx, &revision); err != nil {
+			return chatdomain.Message{}, err
+		}
+
+		// Unread mentions counters of added and removed mentions
+		changedUserIDs, _ := lo.Difference(
+			lo.Union(previousMessage.MentionUserIDs, mentionUserIDs),
+			lo.Intersect(previousMessage.MentionUserIDs, mentionUserIDs),
+		)
+
+		for _, changedUserID := range changedUserIDs {
+			if err := refreshMessageChatMember(ctx, m.database, previousMessage, changedUserID); err != nil {
+				return chatdomain.Message{}, err
+			}
+		}
+
+		return m.Get(ctx, id)
+	})
+
+	if err != nil {
+		return chatdomain.Message{}, err
 	}
 
+	return message, m.updateReplyPreviews(ctx, message)
+}
+
+func (m *MongoMessageRepository) ListRevisions(ctx context.Context, id string) ([]chatdomain.Revision, error) {
 	return mongodatabase.
-		NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+		NewQuery[chatdomain.Revision](m.database.Collection(messageRevisionCollection)).
 		Find(ctx,
 			bson.M{
-				"chat_id": chatID,
+				"message_id": id,
 			},
-			listOptions,
+			options.
+				Find().
+				SetSort(bson.M{"replaced_at": 1}),
+		)
+}
+
+func (m *MongoMessageRepository) AddDeliveries(ctx context.Context, receipts []chatdomain.MessageReceipt) error {
+	if len(receipts) == 0 {
+		return nil
+	}
+
+	_, err := m.database.
+		Collection(messageCollection).
+		BulkWrite(ctx,
+			util.Map(receipts, func(receipt chatdomain.MessageReceipt) mongo.WriteModel {
+				return &mongo.UpdateOneModel{
+					Filter: bson.M{
+						"_id": receipt.MessageID,
+
+						// Sender doesn't receive own messages
+						"user_id": bson.M{"$ne": receipt.Receipt.UserID},
+
+						// Message can be delivered only once
+						"deliveries.user_id": bson.M{"$ne": receipt.Receipt.UserID},
+					},
+					Update: bson.M{
+						"$push": bson.M{
+							"deliveries": receipt.Receipt,
+						},
+					},
+				}
+			}),
+			options.
+				BulkWrite().
+				// Receipts are independent, so failed one must not stop others
+				SetOrdered(false),
 		)
+
+	return err
+}
+
+func (m *MongoMessageRepository) UpdateAttachment(
+	ctx context.Context,
+	attachment chatdomain.Attachment,
+) ([]chatdomain.Message, error) {
+	filter := bson.M{
+		"attachments._id": attachment.ID,
+	}
+
+	if _, err := m.database.
+		Collection(messageCollection).
+		UpdateMany(ctx,
+			filter,
+			bson.M{
+				"$set": bson.M{
+					"attachments.$[attachment]": attachment,
+				},
+			},
+			options.
+				Update().
+				SetArrayFilters(options.ArrayFilters{
+					Filters: bson.A{
+						bson.M{"attachment._id": attachment.ID},
+					},
+				}),
+		); err != nil {
+		return nil, err
+	}
+
+	return mongodatabase.
+		NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+		Find(ctx, filter)
 }
 
-func (m *MongoMessageRepository) UpdateText(ctx context.Context, id, userID, text string) (chatdomain.Message, error) {
+func (m *MongoMessageRepository) UpdateThread(ctx context.Context, rootID string, replyAt time.Time) (chatdomain.Message, error) {
 	return mongodatabase.
 		NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
 		FindOneAndUpdate(ctx,
 			bson.M{
-				"_id": id,
-
-				// Only sender can modify message
-				"user_id": userID,
+				"_id": rootID,
 			},
 			bson.M{
-				"$set": bson.M{
-					"text":       text,
-					"edited":     true,
-					"updated_at": time.Now(),
+				"$inc": bson.M{
+					"thread_reply_count": 1,
 				},
+				"$max": bson.M{
+					"thread_last_reply_at": replyAt,
+				},
+			},
+			options.
+				FindOneAndUpdate().
+				SetReturnDocument(options.After),
+		)
+}
+
+func (m *MongoMessageRepository) AddReaction(ctx context.Context, id, userID, emoji string) (chatdomain.Message, error) {
+	return m.updateReaction(ctx, id, bson.M{
+		"$addToSet": bson.M{
+			reactionKey(emoji): userID,
+		},
+	})
+}
+
+func (m *MongoMessageRepository) RemoveReaction(ctx context.Context, id, userID, emoji string) (chatdomain.Message, error) {
+	return m.updateReaction(ctx, id, bson.M{
+		"$pull": bson.M{
+			reactionKey(emoji): userID,
+		},
+	})
+}
+
+func (m *MongoMessageRepository) updateReaction(ctx context.Context, id string, update bson.M) (chatdomain.Message, error) {
+	// Atomic array operators guarantee that concurrent reactions are not lost
+	return mongodatabase.
+		NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+		FindOneAndUpdate(ctx,
+			bson.M{
+				"_id":     id,
+				"deleted": bson.M{"$ne": true},
 			},
+			update,
 			options.
 				FindOneAndUpdate().
 				SetReturnDocument(options.After),
 		)
 }
+
+func messageCursor(message chatdomain.Message) domain.Cursor {
+	return domain.Cursor{
+		CreatedAt: message.CreatedAt,
+		ID:        message.ID,
+	}
+}
+
+func reactionKey(emoji string) string {
+	return "reactions." + emoji
+}
+
+func (m *MongoMessageRepository) DeleteForUser(ctx context.Context, id, userID string) (chatdomain.Message, error) {
+	return mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (chatdomain.Message, error) {
+		message, err := mongodatabase.
+			NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+			FindOneAndUpdate(ctx,
+				bson.M{
+					"_id": id,
+
+					// Message can't be deleted twice
+					"deleted_user_ids": bson.M{"$ne": userID},
+				},
+				bson.M{
+					"$addToSet": bson.M{
+						"deleted_user_ids": userID,
+					},
+				},
+				options.
+					FindOneAndUpdate().
+					SetReturnDocument(options.After),
+			)
+
+		if err != nil {
+			return chatdomain.Message{}, err
+		}
+
+		// Deleted message may be the last one in user chat list
+		return message, refreshMessageChatMember(ctx, m.database, message, userID)
+	})
+}
+
+func (m *MongoMessageRepository) DeleteForAll(ctx context.Context, id, userID string, sentAfter time.Time) (chatdomain.Message, error) {
+	deletedAt := time.Now()
+
+	message, err := mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (chatdomain.Message, error) {
+		previousMessage, err := mongodatabase.
+			NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+			FindOneAndUpdate(ctx,
+				bson.M{
+					"_id": id,
+
+					// Only sender can delete message for everyone
+					"user_id": userID,
+					"deleted": bson.M{"$ne": true},
+
+					"created_at": bson.M{"$gte": sentAfter},
+				},
+				bson.M{
+					"$set": bson.M{
+						"text":    "",
+						"deleted": true,
+
+						// Deleted polls become empty text messages
+						"kind":       chatdomain.MessageKindText,
+						"updated_at": deletedAt,
+					},
+					"$unset": bson.M{
+						"attachments": "",
+						"reactions":   "",
+						"poll":        "",
+					},
+				},
+				options.
+					FindOneAndUpdate().
+					SetReturnDocument(options.Before),
+			)
+
+		if err != nil {
+			return chatdomain.Message{}, err
+		}
+
+		// Previous versions of text are erased too
+		if _, err := m.database.
+			Collection(messageRevisionCollection).
+			DeleteMany(ctx, bson.M{"message_id": previousMessage.ID}); err != nil {
+			return chatdomain.Message{}, err
+		}
+
+		// Removed attachments are deleted by reaper, if they are not used by other messages
+		if err := addFileCleanups(ctx, m.database, previousMessage.Attachments, deletedAt); err != nil {
+			return chatdomain.Message{}, err
+		}
+
+		if _, err := m.database.
+			Collection(pollVoteCollection).
+			DeleteMany(ctx, bson.M{"message_id": previousMessage.ID}); err != nil {
+			return chatdomain.Message{}, err
+		}
+
+		// Deleted messages are not counted as unread
+		if previousMessage.ThreadRootID == "" {
+			if err := removeUnreadMessage(ctx, m.database, previousMessage); err != nil {
+				return chatdomain.Message{}, err
+			}
+		}
+
+		return m.Get(ctx, id)
+	})
+
+	if err != nil {
+		return chatdomain.Message{}, err
+	}
+
+	return message, m.updateReplyPreviews(ctx, message)
+}
+
+func (m *MongoMessageRepository) ListExpired(ctx context.Context, now time.Time, limit int64) ([]chatdomain.Message, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+		Find(ctx,
+			bson.M{
+				"expire_at": bson.M{"$lte": now},
+			},
+			options.
+				Find().
+				SetSort(bson.M{"expire_at": 1}).
+				SetLimit(limit),
+		)
+}
+
+func (m *MongoMessageRepository) DeleteExpired(ctx context.Context, message chatdomain.Message, now time.Time) (bool, error) {
+	deleted, err := mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (bool, error) {
+		result, err := m.database.
+			Collection(messageCollection).
+			DeleteOne(ctx, bson.M{
+				"_id":       message.ID,
+				"expire_at": bson.M{"$lte": now},
+			})
+
+		// Message was deleted by another instance
+		if err != nil || result.DeletedCount == 0 {
+			return false, err
+		}
+
+		if _, err := m.database.
+			Collection(messageRevisionCollection).
+			DeleteMany(ctx, bson.M{"message_id": message.ID}); err != nil {
+			return false, err
+		}
+
+		if _, err := m.database.
+			Collection(pinCollection).
+			DeleteMany(ctx, bson.M{"message_id": message.ID}); err != nil {
+			return false, err
+		}
+
+		if _, err := m.database.
+			Collection(pollVoteCollection).
+			DeleteMany(ctx, bson.M{"message_id": message.ID}); err != nil {
+			return false, err
+		}
+
+		// Files are deleted after commit, pending cleanups are retried by reaper on failures
+		if err := addFileCleanups(ctx, m.database, message.Attachments, now); err != nil {
+			return false, err
+		}
+
+		// Thread messages don't affect chat lists
+		if message.ThreadRootID != "" {
+			return true, nil
+		}
+
+		if !message.Deleted {
+			if err := removeUnreadMessage(ctx, m.database, message); err != nil {
+				return false, err
+			}
+		}
+
+		// Chat lists, where message was the last one
+		members, err := mongodatabase.
+			NewQuery[chatdomain.ChatMember](m.database.Collection(chatMemberCollection)).
+			Find(ctx, bson.M{
+				"chat_id":    message.ChatID,
+				"message_id": message.ID,
+			})
+
+		if err != nil {
+			return false, err
+		}
+
+		for _, member := range members {
+			if err := refreshChatMember(ctx, m.database, member); err != nil {
+				return false, err
+			}
+		}
+
+		return true, nil
+	})
+
+	if err != nil || !deleted {
+		return false, err
+	}
+
+	// Quotes of message are shown as deleted
+	message.Deleted = true
+	message.Text = ""
+	message.Attachments = nil
+
+	return true, m.updateReplyPreviews(ctx, message)
+}
+
+// addFileCleanups records attachments of erased message, existing records keep their creation time
+func addFileCleanups(ctx context.Context, database *mongo.Database, attachments []chatdomain.Attachment, now time.Time) error {
+	for _, attachment := range attachments {
+		if _, err := database.
+			Collection(fileCleanupCollection).
+			UpdateOne(ctx,
+				bson.M{
+					"_id": attachment.ID,
+				},
+				bson.M{
+					"$setOnInsert": bson.M{
+						"created_at": now,
+					},
+				},
+				options.
+					Update().
+					SetUpsert(true),
+			); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *MongoMessageRepository) ListFileCleanups(ctx context.Context, limit int64) ([]chatdomain.FileCleanup, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.FileCleanup](m.database.Collection(fileCleanupCollection)).
+		Find(ctx,
+			bson.M{},
+			options.
+				Find().
+				SetSort(bson.M{"created_at": 1}).
+				SetLimit(limit),
+		)
+}
+
+func (m *MongoMessageRepository) ClaimFileCleanups(ctx context.Context, ids []string) ([]string, error) {
+	return mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) ([]string, error) {
+		// Files can't be attached concurrently with check
+		for _, id := range ids {
+			if err := mongodatabase.Lock(ctx, m.database, fileLockKey(id)); err != nil {
+				return nil, err
+			}
+		}
+
+		usedIDs, err := m.listAttachmentIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		// Used files are recorded again, when messages with them are erased
+		if _, err := m.DeleteFileCleanups(ctx, usedIDs); err != nil {
+			return nil, err
+		}
+
+		unusedIDs := lo.Without(ids, usedIDs...)
+
+		if _, err := m.database.
+			Collection(fileCleanupCollection).
+			UpdateMany(ctx,
+				bson.M{
+					"_id": bson.M{"$in": unusedIDs},
+				},
+				bson.M{
+					"$set": bson.M{
+						"deleting": true,
+					},
+				},
+			); err != nil {
+			return nil, err
+		}
+
+		return unusedIDs, nil
+	})
+}
+
+func (m *MongoMessageRepository) DeleteFileCleanups(ctx context.Context, ids []string) (int64, error) {
+	result, err := m.database.
+		Collection(fileCleanupCollection).
+		DeleteMany(ctx, bson.M{
+			"_id": bson.M{"$in": ids},
+		})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+// listAttachmentIDs returns provided file ids, which are still attached to any message or scheduled message
+func (m *MongoMessageRepository) listAttachmentIDs(ctx context.Context, fileIDs []string) ([]string, error) {
+	ids, err := m.database.
+		Collection(messageCollection).
+		Distinct(ctx, "attachments._id", bson.M{
+			"attachments._id": bson.M{"$in": fileIDs},
+		})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Files can be attached to pending scheduled messages of the same user
+	scheduledIDs, err := m.database.
+		Collection(scheduledMessageCollection).
+		Distinct(ctx, "attachment_ids", bson.M{
+			"attachment_ids": bson.M{"$in": fileIDs},
+		})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Distinct returns all attachments of found messages
+	return lo.Intersect(fileIDs, util.Map(append(ids, scheduledIDs...), func(id any) string {
+		value, _ := id.(string)
+
+		return value
+	})), nil
+}
+
+// lockFiles serializes attaching of files with their cleanups, must be called inside transaction.
+// Returns repository.ErrFilesDeleted if some of files are claimed for deletion
+func lockFiles(ctx context.Context, database *mongo.Database, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	for _, id := range ids {
+		if err := mongodatabase.Lock(ctx, database, fileLockKey(id)); err != nil {
+			return err
+		}
+	}
+
+	deleting, err := mongodatabase.
+		NewQuery[any](database.Collection(fileCleanupCollection)).
+		Exists(ctx, bson.M{
+			"_id":      bson.M{"$in": ids},
+			"deleting": true,
+		})
+
+	if err != nil {
+		return err
+	}
+
+	if deleting {
+		return repository.ErrFilesDeleted
+	}
+
+	return nil
+}
+
+func fileLockKey(id string) string {
+	return fileCleanupCollection + ":" + id
+}
+
+// updateReplyPreviews keeps previews of quoted message up to date
+func (m *MongoMessageRepository) updateReplyPreviews(ctx context.Context, message chatdomain.Message) error {
+	_, err := m.database.
+		Collection(messageCollection).
+		UpdateMany(ctx,
+			bson.M{
+				"reply_to._id": message.ID,
+			},
+			bson.M{
+				"$set": bson.M{
+					"reply_to": chatdomain.NewMessagePreview(message),
+				},
+			},
+		)
+
+	return err
+}
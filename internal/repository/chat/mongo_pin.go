@@ -0,0 +1,146 @@
+package chatrepo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/fx"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+)
+
+const (
+	pinCollection = "pins"
+)
+
+type MongoPinRepository struct {
+	database *mongo.Database
+}
+
+func NewMongoPinRepository(database *mongo.Database) chatdomain.PinRepository {
+	return &MongoPinRepository{
+		database: database,
+	}
+}
+
+func NewMongoPinMigrationsRunner(lifecycle fx.Lifecycle, logger *zap.Logger, database *mongo.Database) {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("running migrations", zap.String("collection", pinCollection))
+
+			return multierr.Combine(
+				// Message can be pinned only once
+				mongodatabase.
+					NewQuery[any](database.Collection(pinCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("chat_id", "message_id"),
+						options.
+							Index().
+							SetUnique(true),
+					),
+
+				mongodatabase.
+					NewQuery[any](database.Collection(pinCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("chat_id", "created_at"),
+					),
+
+				mongodatabase.
+					NewQuery[any](database.Collection(pinCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("message_id"),
+					),
+			)
+		},
+	})
+}
+
+func (m *MongoPinRepository) Create(ctx context.Context, pin *chatdomain.Pin, limit int64) (bool, error) {
+	return mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (bool, error) {
+		// Concurrent pinning in the same chat can't exceed limit
+		if err := mongodatabase.Lock(ctx, m.database, pinCollection+":"+pin.ChatID); err != nil {
+			return false, err
+		}
+
+		// Checking duplicates before inserting, because failed insert aborts transaction
+		exists, err := mongodatabase.
+			NewQuery[any](m.database.Collection(pinCollection)).
+			Exists(ctx, bson.M{
+				"chat_id":    pin.ChatID,
+				"message_id": pin.MessageID,
+			})
+
+		if err != nil || exists {
+			return false, err
+		}
+
+		count, err := m.count(ctx, pin.ChatID)
+		if err != nil {
+			return false, err
+		}
+
+		if count >= limit {
+			return false, repository.ErrLimitExceeded
+		}
+
+		return mongodatabase.
+			NewQuery[chatdomain.Pin](m.database.Collection(pinCollection)).
+			InsertOne(ctx, pin)
+	})
+}
+
+func (m *MongoPinRepository) List(ctx context.Context, chatID string) ([]chatdomain.Pin, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.Pin](m.database.Collection(pinCollection)).
+		Find(ctx,
+			bson.M{
+				"chat_id": chatID,
+			},
+			options.
+				Find().
+				SetSort(bson.M{"created_at": 1}),
+		)
+}
+
+func (m *MongoPinRepository) count(ctx context.Context, chatID string) (int64, error) {
+	return m.database.
+		Collection(pinCollection).
+		CountDocuments(ctx, bson.M{
+			"chat_id": chatID,
+		})
+}
+
+func (m *MongoPinRepository) Delete(ctx context.Context, chatID, messageID string) (bool, error) {
+	result, err := m.database.
+		Collection(pinCollection).
+		DeleteOne(ctx, bson.M{
+			"chat_id":    chatID,
+			"message_id": messageID,
+		})
+
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
+
+func (m *MongoPinRepository) DeleteByMessage(ctx context.Context, messageID string) (bool, error) {
+	result, err := m.database.
+		Collection(pinCollection).
+		DeleteMany(ctx, bson.M{
+			"message_id": messageID,
+		})
+
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
@@ -0,0 +1,158 @@
+package chatrepo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+)
+
+const (
+	pollVoteCollection = "poll_votes"
+)
+
+func (m *MongoMessageRepository) Vote(ctx context.Context, vote chatdomain.PollVote) (chatdomain.Message, error) {
+	return mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (chatdomain.Message, error) {
+		previous, err := mongodatabase.
+			NewQuery[chatdomain.PollVote](m.database.Collection(pollVoteCollection)).
+			FindOne(ctx, bson.M{
+				"_id": vote.ID,
+			})
+
+		voted := err == nil
+
+		if err != nil && !repository.IsNoDocumentsErr(err) {
+			return chatdomain.Message{}, err
+		}
+
+		if _, err := m.database.
+			Collection(pollVoteCollection).
+			ReplaceOne(ctx,
+				bson.M{
+					"_id": vote.ID,
+				},
+				vote,
+				options.
+					Replace().
+					SetUpsert(true),
+			); err != nil {
+			return chatdomain.Message{}, err
+		}
+
+		// Poll is not found if message was deleted or it's not a poll,
+		// in this case transaction is aborted together with vote
+		return m.updatePoll(ctx,
+			vote.MessageID,
+			lo.Without(vote.OptionIDs, previous.OptionIDs...),
+			lo.Without(previous.OptionIDs, vote.OptionIDs...),
+			lo.Ternary[int64](voted, 0, 1),
+		)
+	})
+}
+
+func (m *MongoMessageRepository) RetractVote(ctx context.Context, id, userID string) (chatdomain.Message, error) {
+	return mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (chatdomain.Message, error) {
+		var vote chatdomain.PollVote
+
+		if err := m.database.
+			Collection(pollVoteCollection).
+			FindOneAndDelete(ctx, bson.M{
+				"_id": chatdomain.PollVoteID(id, userID),
+			}).
+			Decode(&vote); err != nil {
+			return chatdomain.Message{}, err
+		}
+
+		return m.updatePoll(ctx, id, nil, vote.OptionIDs, -1)
+	})
+}
+
+func (m *MongoMessageRepository) ListVotes(ctx context.Context, id string) ([]chatdomain.PollVote, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.PollVote](m.database.Collection(pollVoteCollection)).
+		Find(ctx,
+			bson.M{
+				"message_id": id,
+			},
+			options.
+				Find().
+				SetSort(bson.D{
+					{Key: "created_at", Value: 1},
+					{Key: "_id", Value: 1},
+				}),
+		)
+}
+
+func (m *MongoMessageRepository) GetVote(ctx context.Context, id, userID string) (chatdomain.PollVote, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.PollVote](m.database.Collection(pollVoteCollection)).
+		FindOne(ctx, bson.M{
+			"_id": chatdomain.PollVoteID(id, userID),
+		})
+}
+
+// updatePoll increments counters of added options and voters, and decrements counters of removed options
+func (m *MongoMessageRepository) updatePoll(
+	ctx context.Context,
+	id string,
+	added, removed []int,
+	voters int64,
+) (chatdomain.Message, error) {
+	filter := bson.M{
+		"_id":     id,
+		"kind":    chatdomain.MessageKindPoll,
+		"deleted": bson.M{"$ne": true},
+	}
+
+	counters := bson.M{}
+
+	// Array filters are named after option ids
+	var arrayFilters []any
+
+	for value, optionIDs := range map[int64][]int{1: added, -1: removed} {
+		for _, optionID := range optionIDs {
+			name := fmt.Sprintf("o%d", optionID)
+
+			counters["poll.options.$["+name+"].vote_count"] = value
+			arrayFilters = append(arrayFilters, bson.M{name + ".id": optionID})
+		}
+	}
+
+	if voters != 0 {
+		counters["poll.voter_count"] = voters
+	}
+
+	// Same options were chosen again
+	if len(counters) == 0 {
+		return mongodatabase.
+			NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+			FindOne(ctx, filter)
+	}
+
+	opts := options.
+		FindOneAndUpdate().
+		SetReturnDocument(options.After)
+
+	if len(arrayFilters) > 0 {
+		opts.SetArrayFilters(options.ArrayFilters{
+			Filters: arrayFilters,
+		})
+	}
+
+	// Atomic increments guarantee that concurrent votes are not lost
+	return mongodatabase.
+		NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+		FindOneAndUpdate(ctx,
+			filter,
+			bson.M{
+				"$inc": counters,
+			},
+			opts,
+		)
+}
@@ -0,0 +1,204 @@
+package chatrepo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/fx"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+)
+
+const (
+	scheduledMessageCollection = "scheduled_messages"
+)
+
+type MongoScheduledMessageRepository struct {
+	database *mongo.Database
+}
+
+func NewMongoScheduledMessageRepository(database *mongo.Database) chatdomain.ScheduledMessageRepository {
+	return &MongoScheduledMessageRepository{
+		database: database,
+	}
+}
+
+func NewMongoScheduledMessageMigrationsRunner(lifecycle fx.Lifecycle, logger *zap.Logger, database *mongo.Database) {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("running migrations", zap.String("collection", scheduledMessageCollection))
+
+			return multierr.Combine(
+				// Used by dispatcher
+				mongodatabase.
+					NewQuery[any](database.Collection(scheduledMessageCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("send_at"),
+					),
+
+				mongodatabase.
+					NewQuery[any](database.Collection(scheduledMessageCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("user_id", "chat_id", "send_at"),
+					),
+			)
+		},
+	})
+}
+
+func (m *MongoScheduledMessageRepository) Create(
+	ctx context.Context,
+	message *chatdomain.ScheduledMessage,
+	limit int64,
+) (bool, error) {
+	return mongodatabase.Transaction(ctx, m.database, func(ctx context.Context) (bool, error) {
+		// Concurrent scheduling by user can't exceed limit
+		if err := mongodatabase.Lock(ctx, m.database, scheduledMessageCollection+":"+message.UserID); err != nil {
+			return false, err
+		}
+
+		count, err := m.count(ctx, message.UserID)
+		if err != nil {
+			return false, err
+		}
+
+		if count >= limit {
+			return false, repository.ErrLimitExceeded
+		}
+
+		if err := lockFiles(ctx, m.database, message.AttachmentIDs); err != nil {
+			return false, err
+		}
+
+		return mongodatabase.
+			NewQuery[chatdomain.ScheduledMessage](m.database.Collection(scheduledMessageCollection)).
+			InsertOne(ctx, message)
+	})
+}
+
+func (m *MongoScheduledMessageRepository) Get(ctx context.Context, id, userID string) (chatdomain.ScheduledMessage, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.ScheduledMessage](m.database.Collection(scheduledMessageCollection)).
+		FindOne(ctx, bson.M{
+			"_id": id,
+
+			// Scheduled messages are visible only for sender
+			"user_id": userID,
+		})
+}
+
+func (m *MongoScheduledMessageRepository) List(ctx context.Context, userID, chatID string) ([]chatdomain.ScheduledMessage, error) {
+	filter := bson.M{
+		"user_id": userID,
+	}
+
+	if chatID != "" {
+		filter["chat_id"] = chatID
+	}
+
+	return mongodatabase.
+		NewQuery[chatdomain.ScheduledMessage](m.database.Collection(scheduledMessageCollection)).
+		Find(ctx,
+			filter,
+			options.
+				Find().
+				SetSort(bson.D{
+					{Key: "send_at", Value: 1},
+					{Key: "_id", Value: 1},
+				}),
+		)
+}
+
+func (m *MongoScheduledMessageRepository) count(ctx context.Context, userID string) (int64, error) {
+	return m.database.
+		Collection(scheduledMessageCollection).
+		CountDocuments(ctx, bson.M{
+			"user_id": userID,
+		})
+}
+
+func (m *MongoScheduledMessageRepository) Update(
+	ctx context.Context,
+	id, userID, text string,
+	sendAt, now time.Time,
+) (chatdomain.ScheduledMessage, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.ScheduledMessage](m.database.Collection(scheduledMessageCollection)).
+		FindOneAndUpdate(ctx,
+			unlockedFilter(id, userID, now),
+			bson.M{
+				"$set": bson.M{
+					"text":       text,
+					"send_at":    sendAt,
+					"updated_at": now,
+				},
+			},
+			options.
+				FindOneAndUpdate().
+				SetReturnDocument(options.After),
+		)
+}
+
+func (m *MongoScheduledMessageRepository) Delete(ctx context.Context, id, userID string, now time.Time) (bool, error) {
+	result, err := m.database.
+		Collection(scheduledMessageCollection).
+		DeleteOne(ctx, unlockedFilter(id, userID, now))
+
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
+
+func (m *MongoScheduledMessageRepository) Claim(ctx context.Context, now, lockedUntil time.Time) (chatdomain.ScheduledMessage, error) {
+	return mongodatabase.
+		NewQuery[chatdomain.ScheduledMessage](m.database.Collection(scheduledMessageCollection)).
+		FindOneAndUpdate(ctx,
+			bson.M{
+				"send_at": bson.M{"$lte": now},
+
+				// Message is not locked or previous lease is expired
+				"locked_until": bson.M{"$not": bson.M{"$gt": now}},
+			},
+			bson.M{
+				"$set": bson.M{
+					"locked_until": lockedUntil,
+				},
+			},
+			options.
+				FindOneAndUpdate().
+				SetSort(bson.M{"send_at": 1}).
+				SetReturnDocument(options.After),
+		)
+}
+
+func (m *MongoScheduledMessageRepository) DeleteSent(ctx context.Context, id string) (bool, error) {
+	result, err := m.database.
+		Collection(scheduledMessageCollection).
+		DeleteOne(ctx, bson.M{
+			"_id": id,
+		})
+
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
+
+// unlockedFilter matches message of user, which is not being sent at the moment
+func unlockedFilter(id, userID string, now time.Time) bson.M {
+	return bson.M{
+		"_id":          id,
+		"user_id":      userID,
+		"locked_until": bson.M{"$not": bson.M{"$gt": now}},
+	}
+}
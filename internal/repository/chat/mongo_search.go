@@ -0,0 +1,103 @@
+package chatrepo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
+	"github.com/undefined7887/harmony-backend/internal/util"
+)
+
+// MongoMessageSearchRepository uses text index of messages collection
+type MongoMessageSearchRepository struct {
+	database *mongo.Database
+}
+
+func NewMongoMessageSearchRepository(database *mongo.Database) chatdomain.MessageSearchRepository {
+	return &MongoMessageSearchRepository{
+		database: database,
+	}
+}
+
+func (m *MongoMessageSearchRepository) Search(
+	ctx context.Context,
+	query chatdomain.MessageSearchQuery,
+	pagination domain.Pagination,
+) (domain.Page[chatdomain.MessageSearchResult], error) {
+	filter := bson.M{
+		"$text": bson.M{
+			"$search": query.Text,
+		},
+
+		// Content of deleted messages is erased
+		"deleted": bson.M{"$ne": true},
+
+		// Skipping messages deleted by user
+		"deleted_user_ids": bson.M{"$ne": query.UserID},
+	}
+
+	if query.ChatID != "" {
+		filter["chat_id"] = query.ChatID
+	} else {
+		filter["$or"] = bson.A{
+			// User chats
+			bson.M{"peer_type": chatdomain.PeerTypeUser, "user_id": query.UserID},
+			bson.M{"peer_type": chatdomain.PeerTypeUser, "peer_id": query.UserID},
+
+			// Group chats
+			bson.M{"chat_id": bson.M{"$in": util.Map(query.GroupIDs, func(item string) any {
+				return item
+			})}},
+		}
+	}
+
+	if query.SenderID != "" {
+		filter["user_id"] = query.SenderID
+	}
+
+	createdAt := bson.M{}
+
+	if !query.From.IsZero() {
+		createdAt["$gte"] = query.From
+	}
+
+	if !query.To.IsZero() {
+		createdAt["$lte"] = query.To
+	}
+
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	pipeline := append(
+		bson.A{
+			// Stage with $text must be the first one
+			bson.M{
+				"$match": filter,
+			},
+		},
+		mongodatabase.PaginationStages(pagination, "created_at")...,
+	)
+
+	messages, err := mongodatabase.
+		NewQuery[chatdomain.Message](m.database.Collection(messageCollection)).
+		Aggregate(ctx, pipeline)
+
+	if err != nil {
+		return domain.Page[chatdomain.MessageSearchResult]{}, err
+	}
+
+	return domain.MapPage(
+		mongodatabase.NewPage(messages, pagination, messageCursor),
+		func(message chatdomain.Message) chatdomain.MessageSearchResult {
+			return chatdomain.MessageSearchResult{
+				Message: message,
+				Snippet: chatdomain.NewSnippet(message.Text, query.Text),
+			}
+		},
+	), nil
+}
@@ -6,6 +6,20 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ErrLimitExceeded is returned if entity is not created, because limit of entities is reached
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+func IsLimitExceededErr(err error) bool {
+	return errors.Is(err, ErrLimitExceeded)
+}
+
+// ErrFilesDeleted is returned if entity is not created, because some of its files are being deleted
+var ErrFilesDeleted = errors.New("files are being deleted")
+
+func IsFilesDeletedErr(err error) bool {
+	return errors.Is(err, ErrFilesDeleted)
+}
+
 func IsNoDocumentsErr(err error) bool {
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return true
@@ -0,0 +1,8 @@
+package filerepo
+
+import "go.uber.org/fx"
+
+var Module = fx.Options(
+	fx.Provide(NewMongoRepository),
+	fx.Invoke(NewMongoMigrationsRunner),
+)
@@ -0,0 +1,150 @@
+package filerepo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/fx"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	filedomain "github.com/undefined7887/harmony-backend/internal/domain/file"
+	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
+)
+
+const (
+	fileCollection = "files"
+)
+
+type MongoRepository struct {
+	database *mongo.Database
+}
+
+func NewMongoRepository(database *mongo.Database) filedomain.Repository {
+	return &MongoRepository{
+		database: database,
+	}
+}
+
+func NewMongoMigrationsRunner(lifecycle fx.Lifecycle, logger *zap.Logger, database *mongo.Database) {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("running migrations", zap.String("collection", fileCollection))
+
+			return multierr.Combine(
+				mongodatabase.
+					NewQuery[any](database.Collection(fileCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("user_id"),
+					),
+
+				mongodatabase.
+					NewQuery[any](database.Collection(fileCollection)).
+					BuildIndex(ctx,
+						mongodatabase.IndexKeys("processing"),
+						options.
+							Index().
+							SetSparse(true),
+					),
+			)
+		},
+	})
+}
+
+func (m *MongoRepository) Create(ctx context.Context, file *filedomain.File) (bool, error) {
+	return mongodatabase.
+		NewQuery[filedomain.File](m.database.Collection(fileCollection)).
+		InsertOne(ctx, file)
+}
+
+func (m *MongoRepository) Get(ctx context.Context, id string) (filedomain.File, error) {
+	return mongodatabase.
+		NewQuery[filedomain.File](m.database.Collection(fileCollection)).
+		FindOne(ctx, bson.M{
+			"_id": id,
+		})
+}
+
+func (m *MongoRepository) ListByIDs(ctx context.Context, ids []string) ([]filedomain.File, error) {
+	return mongodatabase.
+		NewQuery[filedomain.File](m.database.Collection(fileCollection)).
+		Find(ctx, bson.M{
+			"_id": bson.M{"$in": ids},
+		})
+}
+
+func (m *MongoRepository) ListProcessing(ctx context.Context) ([]filedomain.File, error) {
+	return mongodatabase.
+		NewQuery[filedomain.File](m.database.Collection(fileCollection)).
+		Find(ctx, bson.M{
+			"processing": true,
+		})
+}
+
+func (m *MongoRepository) Claim(ctx context.Context, id string, now, lockedUntil time.Time) (filedomain.File, error) {
+	return mongodatabase.
+		NewQuery[filedomain.File](m.database.Collection(fileCollection)).
+		FindOneAndUpdate(ctx,
+			bson.M{
+				"_id":        id,
+				"processing": true,
+
+				// File is not locked or previous lease is expired
+				"locked_until": bson.M{"$not": bson.M{"$gt": now}},
+			},
+			bson.M{
+				"$set": bson.M{
+					"locked_until": lockedUntil,
+				},
+			},
+			options.
+				FindOneAndUpdate().
+				SetReturnDocument(options.After),
+		)
+}
+
+func (m *MongoRepository) UpdateMedia(
+	ctx context.Context,
+	id string,
+	width, height int,
+	thumbnails []filedomain.Thumbnail,
+) (filedomain.File, error) {
+	return mongodatabase.
+		NewQuery[filedomain.File](m.database.Collection(fileCollection)).
+		FindOneAndUpdate(ctx,
+			bson.M{
+				"_id": id,
+			},
+			bson.M{
+				"$set": bson.M{
+					"width":      width,
+					"height":     height,
+					"thumbnails": thumbnails,
+				},
+				"$unset": bson.M{
+					"processing":   "",
+					"locked_until": "",
+				},
+			},
+			options.
+				FindOneAndUpdate().
+				SetReturnDocument(options.After),
+		)
+}
+
+func (m *MongoRepository) Delete(ctx context.Context, ids []string) (int64, error) {
+	result, err := m.database.
+		Collection(fileCollection).
+		DeleteMany(ctx, bson.M{
+			"_id": bson.M{"$in": ids},
+		})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
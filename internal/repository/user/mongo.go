@@ -71,6 +71,14 @@ func (m *MongoRepository) GetByEmail(ctx context.Context, email string) (userdom
 		})
 }
 
+func (m *MongoRepository) ListByIDs(ctx context.Context, ids []string) ([]userdomain.User, error) {
+	return mongodatabase.
+		NewQuery[userdomain.User](m.database.Collection(userCollection)).
+		Find(ctx, bson.M{
+			"_id": bson.M{"$in": ids},
+		})
+}
+
 func (m *MongoRepository) GetByNickname(ctx context.Context, nickname string) (userdomain.User, error) {
 	return mongodatabase.
 		NewQuery[userdomain.User](m.database.Collection(userCollection)).
@@ -79,6 +87,14 @@ func (m *MongoRepository) GetByNickname(ctx context.Context, nickname string) (u
 		})
 }
 
+func (m *MongoRepository) ListByNicknames(ctx context.Context, nicknames []string) ([]userdomain.User, error) {
+	return mongodatabase.
+		NewQuery[userdomain.User](m.database.Collection(userCollection)).
+		Find(ctx, bson.M{
+			"nickname": bson.M{"$in": nicknames},
+		})
+}
+
 func (m *MongoRepository) Exists(ctx context.Context, id string) (bool, error) {
 	return mongodatabase.
 		NewQuery[userdomain.User](m.database.Collection(userCollection)).
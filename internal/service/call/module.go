@@ -2,6 +2,7 @@ package callservice
 
 import "go.uber.org/fx"
 
-var Module = fx.Provide(
-	NewService,
+var Module = fx.Options(
+	fx.Provide(NewService),
+	fx.Invoke(NewSweeperRunner),
 )
@@ -3,17 +3,22 @@ package callservice
 import (
 	"context"
 	"encoding/json"
+	"github.com/samber/lo"
+	"github.com/undefined7887/harmony-backend/internal/config"
 	"github.com/undefined7887/harmony-backend/internal/domain"
 	calldomain "github.com/undefined7887/harmony-backend/internal/domain/call"
 	userdomain "github.com/undefined7887/harmony-backend/internal/domain/user"
 	zaplog "github.com/undefined7887/harmony-backend/internal/infrastructure/log/zap"
 	"github.com/undefined7887/harmony-backend/internal/repository"
 	"github.com/undefined7887/harmony-backend/internal/third_party/centrifugo"
+	"go.uber.org/fx"
 	"go.uber.org/zap"
 	"time"
 )
 
 type Service struct {
+	config *config.Call
+
 	userRepository userdomain.Repository
 	callRepository calldomain.Repository
 
@@ -21,19 +26,55 @@ type Service struct {
 }
 
 func NewService(
+	config *config.Call,
 	userRepository userdomain.Repository,
 	callRepository calldomain.Repository,
 	centrifugoClient *centrifugo.Client,
 ) *Service {
 	return &Service{
+		config:           config,
 		userRepository:   userRepository,
 		callRepository:   callRepository,
 		centrifugoClient: centrifugoClient,
 	}
 }
 
+func NewSweeperRunner(lifecycle fx.Lifecycle, logger *zap.Logger, service *Service) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		done        = make(chan struct{})
+	)
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			logger.Info("starting background calls sweeper")
+
+			go func() {
+				defer close(done)
+
+				service.BackgroundSweepCalls(zaplog.PackLogger(ctx, logger), logger)
+			}()
+
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			logger.Info("stopping background calls sweeper")
+
+			cancel()
+
+			select {
+			case <-done:
+				return nil
+
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
 func (s *Service) CreateCall(ctx context.Context, userID, peerID string) (string, error) {
-	if err := s.checkPeer(ctx, userID); err != nil {
+	if err := s.checkPeer(ctx, peerID); err != nil {
 		return "", err
 	}
 
@@ -61,7 +102,7 @@ func (s *Service) CreateCall(ctx context.Context, userID, peerID string) (string
 		ctx,
 		calldomain.ChannelCallNew(peerID),
 		calldomain.NewCallNotification{
-			CallDTO: calldomain.MapCallDTO(call),
+			CallDTO: calldomain.MapUserCallDTO(peerID)(call),
 		},
 	)
 
@@ -78,39 +119,61 @@ func (s *Service) GetCall(ctx context.Context, userID string) (calldomain.CallDT
 		return calldomain.CallDTO{}, err
 	}
 
-	return calldomain.MapCallDTO(call), nil
+	return calldomain.MapUserCallDTO(userID)(call), nil
+}
+
+// ListCalls returns call history of user, including calls in progress.
+// If peerID is provided, only calls with this user are returned, and user must exist
+func (s *Service) ListCalls(
+	ctx context.Context,
+	userID, peerID string,
+	pagination domain.Pagination,
+) (domain.Page[calldomain.CallDTO], error) {
+	if peerID != "" {
+		if err := s.checkPeer(ctx, peerID); err != nil {
+			return domain.Page[calldomain.CallDTO]{}, err
+		}
+	}
+
+	calls, err := s.callRepository.List(ctx, userID, peerID, pagination)
+	if err != nil {
+		return domain.Page[calldomain.CallDTO]{}, err
+	}
+
+	return domain.MapPage(calls, calldomain.MapUserCallDTO(userID)), nil
 }
 
+// UpdateCallStatus moves call to new status, if transition is allowed for role of user in call
 func (s *Service) UpdateCallStatus(ctx context.Context, userID, id string, status string) error {
-	var (
-		matchPeerID           string
-		matchPreviousStatuses []string
-	)
+	call, err := s.callRepository.Get(ctx, id)
+	if repository.IsNoDocumentsErr(err) {
+		return calldomain.ErrCallNotFound()
+	}
 
-	switch status {
-	case calldomain.StatusAccepted:
-		// Call can be accepted only peer
-		matchPeerID = userID
-		matchPreviousStatuses = []string{calldomain.StatusRequest}
+	if err != nil {
+		return err
+	}
 
-	case calldomain.StatusDeclined:
-		// Call can be declined by anyone
-		matchPreviousStatuses = []string{calldomain.StatusRequest}
+	// Calls of other users are hidden
+	role := call.Role(userID)
+	if role == "" {
+		return calldomain.ErrCallNotFound()
+	}
 
-	case calldomain.StatusFinished:
-		// Call can be finished by anyone
-		matchPreviousStatuses = []string{calldomain.StatusRequest, calldomain.StatusAccepted}
+	if err := calldomain.CheckTransition(call.Status, status, role); err != nil {
+		return err
 	}
 
-	call, err := s.callRepository.UpdateStatus(
+	call, err = s.callRepository.UpdateStatus(
 		ctx,
 		id,
-		matchPeerID,
-		matchPreviousStatuses,
-		status,
+		call.Status,
+		calldomain.NewStatusUpdate(userID, status, time.Now()),
 	)
+
+	// Status was changed concurrently, so transition is not valid anymore
 	if repository.IsNoDocumentsErr(err) {
-		return calldomain.ErrCallNotFound()
+		return calldomain.ErrCallTransitionInvalid()
 	}
 
 	if err != nil {
@@ -128,7 +191,7 @@ func (s *Service) UpdateCallStatus(ctx context.Context, userID, id string, statu
 		ctx,
 		calldomain.ChannelCallUpdates(peerID),
 		calldomain.UpdateCallNotification{
-			CallDTO: calldomain.MapCallDTO(call),
+			CallDTO: calldomain.MapUserCallDTO(peerID)(call),
 		},
 	)
 
@@ -145,6 +208,11 @@ func (s *Service) ProxyCallData(ctx context.Context, userID, id, name string, da
 		return err
 	}
 
+	// Only participants can exchange call data
+	if call.Role(userID) == "" {
+		return calldomain.ErrCallNotFound()
+	}
+
 	peerID := call.PeerID
 
 	// Changing peer if peer is a current user
@@ -164,6 +232,105 @@ func (s *Service) ProxyCallData(ctx context.Context, userID, id, name string, da
 	return nil
 }
 
+// BackgroundSweepCalls ends stale calls until context is cancelled
+func (s *Service) BackgroundSweepCalls(ctx context.Context, logger *zap.Logger) {
+	ticker := time.NewTicker(s.config.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.sweepCalls(ctx); err != nil && ctx.Err() == nil {
+				logger.Warn("background sweep calls error", zap.Error(err))
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sweepCalls marks unanswered requests as missed and finishes accepted calls with offline participants
+func (s *Service) sweepCalls(ctx context.Context) error {
+	now := time.Now()
+
+	requests, err := s.callRepository.ListByStatus(ctx, calldomain.StatusRequest, now.Add(-s.config.RingingTimeout))
+	if err != nil {
+		return err
+	}
+
+	for _, call := range requests {
+		if err := s.endCall(ctx, call, calldomain.StatusMissed, now); err != nil {
+			return err
+		}
+	}
+
+	calls, err := s.callRepository.ListByStatus(ctx, calldomain.StatusAccepted, now)
+	if err != nil || len(calls) == 0 {
+		return err
+	}
+
+	userIDs := lo.Uniq(lo.FlatMap(calls, func(call calldomain.Call, _ int) []string {
+		return []string{call.UserID, call.PeerID}
+	}))
+
+	users, err := s.userRepository.ListByIDs(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	onlineUserIDs := lo.FilterMap(users, func(user userdomain.User, _ int) (string, bool) {
+		return user.ID, !user.Offline(now)
+	})
+
+	for _, call := range calls {
+		if lo.Every(onlineUserIDs, []string{call.UserID, call.PeerID}) {
+			continue
+		}
+
+		if err := s.endCall(ctx, call, calldomain.StatusFinished, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// endCall moves call to provided status automatically and notifies both participants
+func (s *Service) endCall(ctx context.Context, call calldomain.Call, status string, now time.Time) error {
+	if err := calldomain.CheckTransition(call.Status, status, calldomain.RoleSystem); err != nil {
+		return err
+	}
+
+	call, err := s.callRepository.UpdateStatus(
+		ctx,
+		call.ID,
+		call.Status,
+		calldomain.NewStatusUpdate("", status, now),
+	)
+
+	// Status was changed by participant concurrently
+	if repository.IsNoDocumentsErr(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range []string{call.UserID, call.PeerID} {
+		s.centrifugoPublish(
+			ctx,
+			calldomain.ChannelCallUpdates(userID),
+			calldomain.UpdateCallNotification{
+				CallDTO: calldomain.MapUserCallDTO(userID)(call),
+			},
+		)
+	}
+
+	return nil
+}
+
 func (s *Service) checkPeer(ctx context.Context, peerID string) error {
 	exists, err := s.userRepository.Exists(ctx, peerID)
 	if err != nil {
@@ -0,0 +1,107 @@
+package callservice
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	calldomain "github.com/undefined7887/harmony-backend/internal/domain/call"
+	userdomain "github.com/undefined7887/harmony-backend/internal/domain/user"
+	"github.com/undefined7887/harmony-backend/internal/third_party/centrifugo"
+)
+
+var (
+	testCallerID = domain.ID()
+	testCalleeID = domain.ID()
+)
+
+// fakeUserRepository knows only given users, not implemented methods panic
+type fakeUserRepository struct {
+	userdomain.Repository
+
+	userIDs []string
+}
+
+func (f *fakeUserRepository) Exists(_ context.Context, id string) (bool, error) {
+	for _, userID := range f.userIDs {
+		if userID == id {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// fakeCallRepository stores created calls, not implemented methods panic
+type fakeCallRepository struct {
+	calldomain.Repository
+
+	calls []calldomain.Call
+}
+
+func (f *fakeCallRepository) Create(_ context.Context, call *calldomain.Call) (bool, error) {
+	f.calls = append(f.calls, *call)
+
+	return true, nil
+}
+
+func newTestService(t *testing.T, userIDs ...string) (*Service, *fakeCallRepository) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		_, _ = w.Write([]byte(`{"result":{}}`))
+	}))
+
+	t.Cleanup(server.Close)
+
+	callRepository := &fakeCallRepository{}
+
+	service := NewService(
+		&config.Call{},
+		&fakeUserRepository{userIDs: userIDs},
+		callRepository,
+		centrifugo.NewClient(&config.Centrifugo{
+			ApiAddress: server.URL,
+		}),
+	)
+
+	return service, callRepository
+}
+
+func TestCreateCallChecksCallee(t *testing.T) {
+	t.Run("existing callee", func(t *testing.T) {
+		service, callRepository := newTestService(t, testCallerID, testCalleeID)
+
+		id, err := service.CreateCall(context.Background(), testCallerID, testCalleeID)
+		require.NoError(t, err)
+
+		require.Len(t, callRepository.calls, 1)
+		assert.Equal(t, id, callRepository.calls[0].ID)
+		assert.Equal(t, testCalleeID, callRepository.calls[0].PeerID)
+	})
+
+	t.Run("unknown callee", func(t *testing.T) {
+		// Caller is authenticated, so only callee can be missing
+		service, callRepository := newTestService(t, testCallerID)
+
+		_, err := service.CreateCall(context.Background(), testCallerID, testCalleeID)
+		assert.True(t, domain.IsError(err, userdomain.ErrUserNotFound()))
+
+		assert.Empty(t, callRepository.calls)
+	})
+}
+
+func TestListCallsChecksPeer(t *testing.T) {
+	service, _ := newTestService(t, testCallerID)
+
+	_, err := service.ListCalls(context.Background(), testCallerID, testCalleeID, domain.Pagination{
+		Limit: domain.PaginationDefaultLimit,
+	})
+	assert.True(t, domain.IsError(err, userdomain.ErrUserNotFound()))
+}
@@ -1,7 +1,20 @@
 package chatservice
 
-import "go.uber.org/fx"
+import (
+	"go.uber.org/fx"
 
-var Module = fx.Provide(
-	NewService,
+	fileservice "github.com/undefined7887/harmony-backend/internal/service/file"
 )
+
+var Module = fx.Options(
+	fx.Provide(NewService),
+	fx.Invoke(RegisterFileHandlers),
+	fx.Invoke(NewReceiptWriterRunner),
+	fx.Invoke(NewScheduledDispatcherRunner),
+	fx.Invoke(NewMessageReaperRunner),
+)
+
+// RegisterFileHandlers keeps message attachments up to date with processed files
+func RegisterFileHandlers(fileService *fileservice.Service, service *Service) {
+	fileService.OnFileProcessed(service.UpdateAttachments)
+}
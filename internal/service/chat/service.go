@@ -4,134 +4,368 @@ import (
 	"context"
 	"time"
 
+	"github.com/samber/lo"
 	"go.uber.org/zap"
 
+	"github.com/undefined7887/harmony-backend/internal/config"
 	"github.com/undefined7887/harmony-backend/internal/domain"
 	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	filedomain "github.com/undefined7887/harmony-backend/internal/domain/file"
 	userdomain "github.com/undefined7887/harmony-backend/internal/domain/user"
 	zaplog "github.com/undefined7887/harmony-backend/internal/infrastructure/log/zap"
 	"github.com/undefined7887/harmony-backend/internal/repository"
+	fileservice "github.com/undefined7887/harmony-backend/internal/service/file"
 	"github.com/undefined7887/harmony-backend/internal/third_party/centrifugo"
 	"github.com/undefined7887/harmony-backend/internal/util"
 )
 
 type Service struct {
-	userRepository    userdomain.Repository
-	messageRepository chatdomain.MessageRepository
-	chatRepository    chatdomain.ChatRepository
+	config *config.Chat
+
+	userRepository      userdomain.Repository
+	messageRepository   chatdomain.MessageRepository
+	searchRepository    chatdomain.MessageSearchRepository
+	chatRepository      chatdomain.ChatRepository
+	groupRepository     chatdomain.GroupRepository
+	pinRepository       chatdomain.PinRepository
+	folderRepository    chatdomain.FolderRepository
+	draftRepository     chatdomain.DraftRepository
+	scheduledRepository chatdomain.ScheduledMessageRepository
+
+	fileService *fileservice.Service
 
 	centrifugoClient *centrifugo.Client
+
+	// Delivery receipts waiting for writing, see service_receipt.go
+	deliveries chan delivery
 }
 
 func NewService(
+	config *config.Chat,
 	userRepository userdomain.Repository,
 	messageRepository chatdomain.MessageRepository,
+	searchRepository chatdomain.MessageSearchRepository,
 	chatRepository chatdomain.ChatRepository,
+	groupRepository chatdomain.GroupRepository,
+	pinRepository chatdomain.PinRepository,
+	folderRepository chatdomain.FolderRepository,
+	draftRepository chatdomain.DraftRepository,
+	scheduledRepository chatdomain.ScheduledMessageRepository,
+	fileService *fileservice.Service,
 	centrifugoClient *centrifugo.Client,
 ) *Service {
 	return &Service{
-		userRepository:    userRepository,
-		messageRepository: messageRepository,
-		chatRepository:    chatRepository,
-		centrifugoClient:  centrifugoClient,
+		config:              config,
+		userRepository:      userRepository,
+		messageRepository:   messageRepository,
+		searchRepository:    searchRepository,
+		chatRepository:      chatRepository,
+		groupRepository:     groupRepository,
+		pinRepository:       pinRepository,
+		folderRepository:    folderRepository,
+		draftRepository:     draftRepository,
+		scheduledRepository: scheduledRepository,
+		fileService:         fileService,
+		centrifugoClient:    centrifugoClient,
+		deliveries:          make(chan delivery, config.ReceiptsQueueSize),
 	}
 }
 
-func (s *Service) CreateMessage(ctx context.Context, userID, peerID, peerType, text string) (chatdomain.MessageDTO, error) {
-	if err := s.checkPeer(ctx, peerID, peerType); err != nil {
+func (s *Service) CreateMessage(
+	ctx context.Context,
+	userID, peerID, peerType, text string,
+	attachmentIDs []string,
+	poll *chatdomain.Poll,
+	replyToID, threadRootID string,
+) (chatdomain.MessageDTO, error) {
+	if err := s.checkPoll(poll, time.Now()); err != nil {
 		return chatdomain.MessageDTO{}, err
 	}
 
-	now := time.Now()
-
-	message := chatdomain.Message{
-		ID:          domain.ID(),
-		UserID:      userID,
-		PeerID:      peerID,
-		PeerType:    peerType,
-		ChatID:      chatdomain.ChatID(userID, peerID, peerType),
-		Text:        text,
-		ReadUserIDs: []string{}, // Required to be not nil
-		CreatedAt:   now,
-		UpdatedAt:   now,
+	message, err := s.prepareMessage(ctx, userID, peerID, peerType, text, attachmentIDs, poll, replyToID, threadRootID)
+	if err != nil {
+		return chatdomain.MessageDTO{}, err
 	}
 
-	if _, err := s.messageRepository.Create(ctx, &message); err != nil {
+	if err := s.createMessage(ctx, &message); err != nil {
 		return chatdomain.MessageDTO{}, err
 	}
 
-	switch peerType {
-	case chatdomain.PeerTypeUser:
-		s.centrifugoPublish(
-			ctx,
-			chatdomain.ChannelMessageNew(userID),
-			chatdomain.NewMessageNotification{
-				MessageDTO: chatdomain.MapMessageDTO(message),
-			},
-		)
+	// Draft was sent, thread replies are written outside of chat composer
+	if threadRootID == "" {
+		if _, err := s.deleteDraft(ctx, userID, peerID, peerType); err != nil {
+			return chatdomain.MessageDTO{}, err
+		}
+	}
 
-		s.centrifugoPublish(
-			ctx,
-			chatdomain.ChannelMessageNew(peerID),
-			chatdomain.NewMessageNotification{
-				MessageDTO: chatdomain.MapMessageDTO(message),
-			},
-		)
+	return chatdomain.MapMessageDTO(message), nil
+}
 
-	default:
-		return chatdomain.MessageDTO{}, domain.ErrNotImplemented()
+func (s *Service) ForwardMessage(
+	ctx context.Context,
+	userID, id string,
+	targets []chatdomain.ForwardTarget,
+) ([]chatdomain.MessageDTO, error) {
+	// Only participants of source chat can forward messages
+	source, err := s.getMessage(ctx, userID, id)
+	if err != nil {
+		return nil, err
 	}
 
-	return chatdomain.MapMessageDTO(message), nil
+	if source.Deleted {
+		return nil, chatdomain.ErrMessageNotFound()
+	}
+
+	// Checking all targets before sending anything
+	for _, target := range targets {
+		if err := s.checkPeer(ctx, userID, target.PeerID, target.PeerType); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]chatdomain.MessageDTO, 0, len(targets))
+
+	for _, target := range lo.Uniq(targets) {
+		message := newMessage(userID, target.PeerID, target.PeerType, source.Text)
+		message.Attachments = source.Attachments
+
+		// Forwarded poll is a new poll, votes are not copied
+		if source.Poll != nil {
+			message.Kind = chatdomain.MessageKindPoll
+			message.Poll = source.Poll.Copy()
+		}
+		message.ForwardedFrom = chatdomain.NewMessageForward(source)
+
+		if err := s.createMessage(ctx, &message); err != nil {
+			return nil, err
+		}
+
+		result = append(result, chatdomain.MapMessageDTO(message))
+	}
+
+	return result, nil
 }
 
 func (s *Service) GetMessage(ctx context.Context, userID, id string) (chatdomain.MessageDTO, error) {
-	message, err := s.messageRepository.Get(ctx, id)
-	if repository.IsNoDocumentsErr(err) {
-		return chatdomain.MessageDTO{}, chatdomain.ErrMessageNotFound()
+	message, err := s.getMessage(ctx, userID, id)
+	if err != nil {
+		return chatdomain.MessageDTO{}, err
 	}
 
+	// Only participants, who read message, are loaded
+	readStates, err := s.chatRepository.ListReads(ctx, message.ChatID, message.CreatedAt)
 	if err != nil {
 		return chatdomain.MessageDTO{}, err
 	}
 
-	switch message.PeerType {
-	case chatdomain.PeerTypeUser:
-		chatID := chatdomain.ChatID(userID, message.PeerID, message.PeerType)
+	return chatdomain.MapUserMessageReadsDTO(userID, readStates)(message), nil
+}
 
-		// Checking that user is participant of this chat
-		if chatID != message.ChatID {
-			return chatdomain.MessageDTO{}, domain.ErrForbidden()
-		}
+// GetMessageAttachment returns attached file with download url
+func (s *Service) GetMessageAttachment(ctx context.Context, userID, id, fileID string) (filedomain.FileDTO, error) {
+	// Attachments are available for all participants of message chat
+	message, err := s.getMessage(ctx, userID, id)
+	if err != nil {
+		return filedomain.FileDTO{}, err
+	}
 
-	case chatdomain.PeerTypeGroup:
-		return chatdomain.MessageDTO{}, domain.ErrNotImplemented()
+	attached := lo.ContainsBy(message.Attachments, func(attachment chatdomain.Attachment) bool {
+		return attachment.ID == fileID
+	})
+
+	if !attached {
+		return filedomain.FileDTO{}, filedomain.ErrFileNotFound()
 	}
 
-	return chatdomain.MapMessageDTO(message), nil
+	return s.fileService.GetFileURL(ctx, fileID)
+}
+
+// UpdateAttachments refreshes attachment snapshots of messages after file processing
+func (s *Service) UpdateAttachments(ctx context.Context, file filedomain.File) {
+	messages, err := s.messageRepository.UpdateAttachment(ctx, chatdomain.NewAttachment(file))
+	if err != nil {
+		zaplog.
+			UnpackLogger(ctx).
+			Warn("failed to update attachments", zap.String("file_id", file.ID), zap.Error(err))
+
+		return
+	}
+
+	for _, message := range messages {
+		chatUserIDs, err := s.chatUserIDs(ctx, message.UserID, message.PeerID, message.PeerType)
+		if err != nil {
+			zaplog.
+				UnpackLogger(ctx).
+				Warn("failed to get chat users", zap.String("message_id", message.ID), zap.Error(err))
+
+			continue
+		}
+
+		s.centrifugoBroadcast(
+			ctx,
+			util.Map(chatUserIDs, chatdomain.ChannelMessageUpdates),
+			chatdomain.UpdateMessageNotification{
+				MessageDTO: chatdomain.MapMessageDTO(message),
+			},
+		)
+	}
 }
 
 func (s *Service) ListMessages(
 	ctx context.Context,
 	userID, peerID, peerType string,
-	offset, limit int64,
-) ([]chatdomain.MessageDTO, error) {
+	pagination domain.Pagination,
+) (domain.Page[chatdomain.MessageDTO], error) {
+	if peerType == chatdomain.PeerTypeGroup {
+		// Only members can read group messages
+		if err := s.checkPeer(ctx, userID, peerID, peerType); err != nil {
+			return domain.Page[chatdomain.MessageDTO]{}, err
+		}
+	}
+
 	chatID := chatdomain.ChatID(userID, peerID, peerType)
 
-	messages, err := s.messageRepository.List(ctx, chatID, offset, limit)
+	messages, err := s.messageRepository.List(ctx, chatID, userID, pagination)
 	if err != nil {
-		return nil, err
+		return domain.Page[chatdomain.MessageDTO]{}, err
 	}
 
-	if len(messages) == 0 {
-		return nil, chatdomain.ErrMessageNotFound()
+	if len(messages.Items) == 0 {
+		return domain.Page[chatdomain.MessageDTO]{}, chatdomain.ErrMessageNotFound()
+	}
+
+	readSummaries, err := s.chatRepository.ListReadSummaries(ctx, userID, []string{chatID})
+	if err != nil {
+		return domain.Page[chatdomain.MessageDTO]{}, err
+	}
+
+	return domain.MapPage(messages, chatdomain.MapUserMessageDTO(userID, readSummaries)), nil
+}
+
+func (s *Service) ListThreadMessages(
+	ctx context.Context,
+	userID, id string,
+	pagination domain.Pagination,
+) (domain.Page[chatdomain.MessageDTO], error) {
+	// Thread is available for all participants of root message chat
+	threadRoot, err := s.getMessage(ctx, userID, id)
+	if err != nil {
+		return domain.Page[chatdomain.MessageDTO]{}, err
+	}
+
+	messages, err := s.messageRepository.ListThread(ctx, id, userID, pagination)
+	if err != nil {
+		return domain.Page[chatdomain.MessageDTO]{}, err
+	}
+
+	if len(messages.Items) == 0 {
+		return domain.Page[chatdomain.MessageDTO]{}, chatdomain.ErrMessageNotFound()
+	}
+
+	readSummaries, err := s.chatRepository.ListReadSummaries(ctx, userID, []string{threadRoot.ChatID})
+	if err != nil {
+		return domain.Page[chatdomain.MessageDTO]{}, err
+	}
+
+	return domain.MapPage(messages, chatdomain.MapUserMessageDTO(userID, readSummaries)), nil
+}
+
+func (s *Service) SearchMessages(
+	ctx context.Context,
+	userID, text string,
+	peerID, peerType, senderID string,
+	from, to time.Time,
+	pagination domain.Pagination,
+) (domain.Page[chatdomain.MessageSearchResultDTO], error) {
+	query := chatdomain.MessageSearchQuery{
+		Text:     text,
+		UserID:   userID,
+		SenderID: senderID,
+		From:     from,
+		To:       to,
+	}
+
+	if peerID != "" {
+		if peerType == chatdomain.PeerTypeGroup {
+			// Only members can search group messages
+			if err := s.checkPeer(ctx, userID, peerID, peerType); err != nil {
+				return domain.Page[chatdomain.MessageSearchResultDTO]{}, err
+			}
+		}
+
+		query.ChatID = chatdomain.ChatID(userID, peerID, peerType)
+	} else {
+		groupIDs, err := s.groupRepository.ListUserGroupIDs(ctx, userID)
+		if err != nil {
+			return domain.Page[chatdomain.MessageSearchResultDTO]{}, err
+		}
+
+		query.GroupIDs = groupIDs
+	}
+
+	results, err := s.searchRepository.Search(ctx, query, pagination)
+	if err != nil {
+		return domain.Page[chatdomain.MessageSearchResultDTO]{}, err
 	}
 
-	return util.Map(messages, chatdomain.MapMessageDTO), nil
+	if len(results.Items) == 0 {
+		return domain.Page[chatdomain.MessageSearchResultDTO]{}, chatdomain.ErrMessageNotFound()
+	}
+
+	// Results can be found in different chats
+	readSummaries, err := s.chatRepository.ListReadSummaries(ctx, userID, lo.Uniq(util.Map(results.Items,
+		func(result chatdomain.MessageSearchResult) string {
+			return result.Message.ChatID
+		},
+	)))
+	if err != nil {
+		return domain.Page[chatdomain.MessageSearchResultDTO]{}, err
+	}
+
+	return domain.MapPage(results, chatdomain.MapUserMessageSearchResultDTO(userID, readSummaries)), nil
 }
 
 func (s *Service) UpdateMessage(ctx context.Context, userID, id, text string) (chatdomain.MessageDTO, error) {
-	updatedMessage, err := s.messageRepository.UpdateText(ctx, id, userID, text)
+	message, err := s.getMessage(ctx, userID, id)
+	if err != nil {
+		return chatdomain.MessageDTO{}, err
+	}
+
+	if message.UserID != userID {
+		return chatdomain.MessageDTO{}, domain.ErrForbidden()
+	}
+
+	if message.Deleted {
+		return chatdomain.MessageDTO{}, chatdomain.ErrMessageNotFound()
+	}
+
+	// Text of poll is its question, which can't be changed after voting started
+	if message.Poll != nil {
+		return chatdomain.MessageDTO{}, domain.ErrForbidden()
+	}
+
+	sentAfter := time.Now().Add(-s.config.EditWindow)
+
+	if message.CreatedAt.Before(sentAfter) {
+		return chatdomain.MessageDTO{}, chatdomain.ErrMessageEditWindowExpired()
+	}
+
+	// Nothing changed, so revision is not created
+	if message.Text == text {
+		return chatdomain.MapMessageDTO(message), nil
+	}
+
+	chatUserIDs, err := s.chatUserIDs(ctx, message.UserID, message.PeerID, message.PeerType)
+	if err != nil {
+		return chatdomain.MessageDTO{}, err
+	}
+
+	mentionUserIDs, err := s.resolveMentions(ctx, userID, text, chatUserIDs)
+	if err != nil {
+		return chatdomain.MessageDTO{}, err
+	}
+
+	updatedMessage, err := s.messageRepository.UpdateText(ctx, id, userID, text, mentionUserIDs, sentAfter)
 	if repository.IsNoDocumentsErr(err) {
 		return chatdomain.MessageDTO{}, chatdomain.ErrMessageNotFound()
 	}
@@ -140,73 +374,379 @@ func (s *Service) UpdateMessage(ctx context.Context, userID, id, text string) (c
 		return chatdomain.MessageDTO{}, err
 	}
 
-	switch updatedMessage.PeerType {
-	case chatdomain.PeerTypeUser:
-		s.centrifugoPublish(
+	s.centrifugoBroadcast(
+		ctx,
+		util.Map(chatUserIDs, chatdomain.ChannelMessageUpdates),
+		chatdomain.UpdateMessageNotification{
+			MessageDTO: chatdomain.MapMessageDTO(updatedMessage),
+		},
+	)
+
+	// Only users, who weren't mentioned before, are notified
+	s.publishMentions(ctx, updatedMessage, lo.Without(mentionUserIDs, message.MentionUserIDs...))
+
+	return chatdomain.MapMessageDTO(updatedMessage), nil
+}
+
+// GetMessageHistory returns previous versions of message text, available for chat participants
+func (s *Service) GetMessageHistory(ctx context.Context, userID, id string) ([]chatdomain.RevisionDTO, error) {
+	message, err := s.getMessage(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Content of deleted messages is erased
+	if message.Deleted {
+		return nil, chatdomain.ErrMessageNotFound()
+	}
+
+	revisions, err := s.messageRepository.ListRevisions(ctx, message.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Map(revisions, chatdomain.MapRevisionDTO), nil
+}
+
+func (s *Service) DeleteMessage(ctx context.Context, userID, id string, forEveryone bool) error {
+	message, err := s.getMessage(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if !forEveryone {
+		if _, err := s.messageRepository.DeleteForUser(ctx, id, userID); err != nil {
+			if repository.IsNoDocumentsErr(err) {
+				return chatdomain.ErrMessageNotFound()
+			}
+
+			return err
+		}
+
+		// Only other sessions of current user must be notified
+		s.centrifugoBroadcast(
 			ctx,
-			chatdomain.ChannelMessageNew(updatedMessage.UserID),
-			chatdomain.NewMessageNotification{
-				MessageDTO: chatdomain.MapMessageDTO(updatedMessage),
-			},
+			[]string{chatdomain.ChannelMessageDeletes(userID)},
+			mapDeleteMessageNotification(message, false),
 		)
 
-		s.centrifugoPublish(
+		return nil
+	}
+
+	if message.UserID != userID {
+		return domain.ErrForbidden()
+	}
+
+	if message.Deleted {
+		return chatdomain.ErrMessageNotFound()
+	}
+
+	sentAfter := time.Now().Add(-s.config.DeleteWindow)
+
+	if message.CreatedAt.Before(sentAfter) {
+		return chatdomain.ErrMessageDeleteWindowExpired()
+	}
+
+	if _, err := s.messageRepository.DeleteForAll(ctx, id, userID, sentAfter); err != nil {
+		if repository.IsNoDocumentsErr(err) {
+			return chatdomain.ErrMessageNotFound()
+		}
+
+		return err
+	}
+
+	chatUserIDs, err := s.chatUserIDs(ctx, message.UserID, message.PeerID, message.PeerType)
+	if err != nil {
+		return err
+	}
+
+	s.centrifugoBroadcast(
+		ctx,
+		util.Map(chatUserIDs, chatdomain.ChannelMessageDeletes),
+		mapDeleteMessageNotification(message, true),
+	)
+
+	// Deleted messages can't stay pinned
+	unpinned, err := s.pinRepository.DeleteByMessage(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if unpinned {
+		s.centrifugoBroadcast(
 			ctx,
-			chatdomain.ChannelMessageUpdates(updatedMessage.PeerID),
-			chatdomain.UpdateMessageNotification{
-				MessageDTO: chatdomain.MapMessageDTO(updatedMessage),
+			util.Map(chatUserIDs, chatdomain.ChannelPinUpdates),
+			chatdomain.UpdatePinNotification{
+				UserID:    userID,
+				PeerID:    message.PeerID,
+				PeerType:  message.PeerType,
+				MessageID: message.ID,
+				Pinned:    false,
 			},
 		)
+	}
 
-	default:
-		return chatdomain.MessageDTO{}, domain.ErrNotImplemented()
+	return nil
+}
+
+func (s *Service) UpdateMessageReaction(ctx context.Context, userID, id, emoji string, reacted bool) error {
+	// Reactions are available for all chat participants
+	if _, err := s.getMessage(ctx, userID, id); err != nil {
+		return err
 	}
 
-	return chatdomain.MapMessageDTO(updatedMessage), nil
+	var (
+		updatedMessage chatdomain.Message
+		err            error
+	)
+
+	if reacted {
+		updatedMessage, err = s.messageRepository.AddReaction(ctx, id, userID, emoji)
+	} else {
+		updatedMessage, err = s.messageRepository.RemoveReaction(ctx, id, userID, emoji)
+	}
+
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.ErrMessageNotFound()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	chatUserIDs, err := s.chatUserIDs(ctx, updatedMessage.UserID, updatedMessage.PeerID, updatedMessage.PeerType)
+	if err != nil {
+		return err
+	}
+
+	s.centrifugoBroadcast(
+		ctx,
+		util.Map(chatUserIDs, chatdomain.ChannelMessageUpdates),
+		chatdomain.UpdateMessageNotification{
+			MessageDTO: chatdomain.MapMessageDTO(updatedMessage),
+			Reaction: &chatdomain.ReactionUpdateDTO{
+				UserID:  userID,
+				Emoji:   emoji,
+				Reacted: reacted,
+			},
+		},
+	)
+
+	return nil
 }
 
+// ListChats returns page of chats, pinned chats are returned separately only for the first page
 func (s *Service) ListChats(
 	ctx context.Context,
-	userID, peerType string,
-	offset, limit int64,
-) ([]chatdomain.ChatDTO, error) {
-	chats, err := s.chatRepository.List(ctx, userID, peerType, offset, limit)
+	userID, folderID string,
+	filter chatdomain.ChatFilter,
+	pagination domain.Pagination,
+) (pinned []chatdomain.ChatDTO, page domain.Page[chatdomain.ChatDTO], err error) {
+	if folderID != "" {
+		folderFilter, err := s.getFolderFilter(ctx, userID, folderID)
+		if err != nil {
+			return nil, domain.Page[chatdomain.ChatDTO]{}, err
+		}
+
+		filter.Folder = folderFilter
+	}
+
+	chats, err := s.chatRepository.List(ctx, userID, filter, pagination)
 	if err != nil {
-		return nil, err
+		return nil, domain.Page[chatdomain.ChatDTO]{}, err
 	}
 
-	if len(chats) == 0 {
-		return nil, chatdomain.ErrChatsNotFound()
+	var pinnedChats []chatdomain.Chat
+
+	if pagination.Before == nil && pagination.After == nil && pagination.Offset == 0 {
+		if pinnedChats, err = s.chatRepository.ListPinned(ctx, userID, filter); err != nil {
+			return nil, domain.Page[chatdomain.ChatDTO]{}, err
+		}
+	}
+
+	if len(chats.Items) == 0 && len(pinnedChats) == 0 {
+		return nil, domain.Page[chatdomain.ChatDTO]{}, chatdomain.ErrChatsNotFound()
 	}
 
-	return util.Map(chats, chatdomain.MapChatDTO), nil
+	readSummaries, err := s.chatRepository.ListReadSummaries(ctx, userID, util.Map(
+		append(pinnedChats, chats.Items...),
+		func(chat chatdomain.Chat) string {
+			return chat.Message.ChatID
+		},
+	))
+
+	if err != nil {
+		return nil, domain.Page[chatdomain.ChatDTO]{}, err
+	}
+
+	mapChatDTO := chatdomain.MapUserChatDTO(userID, readSummaries)
+
+	return util.Map(pinnedChats, mapChatDTO), domain.MapPage(chats, mapChatDTO), nil
 }
 
-func (s *Service) UpdateChatRead(ctx context.Context, userID, peerID, peerType string) error {
-	chatID := chatdomain.ChatID(userID, peerID, peerType)
+// UpdateChatRead marks chat messages as read up to provided message, or all of them if messageID is empty
+func (s *Service) UpdateChatRead(ctx context.Context, userID, peerID, peerType, messageID string) error {
+	if peerType == chatdomain.PeerTypeGroup {
+		// Only members can read group messages
+		if err := s.checkPeer(ctx, userID, peerID, peerType); err != nil {
+			return err
+		}
+	}
+
+	var (
+		chatID  = chatdomain.ChatID(userID, peerID, peerType)
+		message chatdomain.Message
+	)
+
+	if messageID != "" {
+		var err error
+
+		message, err = s.getMessage(ctx, userID, messageID)
+		if err != nil {
+			return err
+		}
+
+		if message.ChatID != chatID {
+			return chatdomain.ErrMessageNotFound()
+		}
+	} else {
+		// Reading all messages up to the latest one
+		messages, err := s.messageRepository.List(ctx, chatID, userID, domain.Pagination{Limit: 1})
+		if err != nil {
+			return err
+		}
+
+		if len(messages.Items) == 0 {
+			return chatdomain.ErrMessageNotFound()
+		}
+
+		message = messages.Items[0]
+	}
+
+	readAt := time.Now()
+
+	updated, err := s.chatRepository.UpdateRead(ctx, chatdomain.ReadState{
+		ID:               chatdomain.ReadStateID(chatID, userID),
+		ChatID:           chatID,
+		UserID:           userID,
+		MessageID:        message.ID,
+		MessageCreatedAt: message.CreatedAt,
+		UpdatedAt:        readAt,
+	})
 
-	count, err := s.chatRepository.UpdateRead(ctx, userID, chatID)
 	if err != nil {
 		return err
 	}
 
-	if count == 0 {
+	// Messages were already read
+	if !updated {
 		return chatdomain.ErrMessageNotFound()
 	}
 
-	switch peerType {
-	case chatdomain.PeerTypeUser:
-		data := chatdomain.UpdateChatReadNotification{
+	chatUserIDs, err := s.chatUserIDs(ctx, userID, peerID, peerType)
+	if err != nil {
+		return err
+	}
+
+	// Publishing for current user and all peers
+	s.centrifugoBroadcast(
+		ctx,
+		util.Map(chatUserIDs, chatdomain.ChannelReadUpdates),
+		chatdomain.UpdateChatReadNotification{
+			UserID:    userID,
+			PeerID:    peerID,
+			PeerType:  peerType,
+			MessageID: message.ID,
+			ReadAt:    readAt,
+		},
+	)
+
+	return nil
+}
+
+// UpdateChatSettings replaces settings of user in chat
+func (s *Service) UpdateChatSettings(ctx context.Context, userID, peerID, peerType string, settings chatdomain.ChatSettings) error {
+	if err := s.checkPeer(ctx, userID, peerID, peerType); err != nil {
+		return err
+	}
+
+	member, err := s.chatRepository.UpdateSettings(ctx, chatdomain.ChatMember{
+		ChatID:       chatdomain.ChatID(userID, peerID, peerType),
+		UserID:       userID,
+		PeerID:       peerID,
+		PeerType:     peerType,
+		ChatSettings: settings,
+	}, s.config.PinnedChatsLimit)
+
+	if repository.IsLimitExceededErr(err) {
+		return chatdomain.ErrPinnedChatsLimitExceeded()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Syncing other sessions of user
+	s.centrifugoBroadcast(
+		ctx,
+		[]string{chatdomain.ChannelSettingsUpdates(userID)},
+		chatdomain.UpdateChatSettingsNotification{
+			PeerID:          peerID,
+			PeerType:        peerType,
+			ChatSettingsDTO: chatdomain.MapChatSettingsDTO(member.ChatSettings),
+		},
+	)
+
+	return nil
+}
+
+func (s *Service) UpdateChatTyping(ctx context.Context, userID, peerID, peerType string, typing bool) error {
+	if err := s.checkPeer(ctx, userID, peerID, peerType); err != nil {
+		return err
+	}
+
+	chatUserIDs, err := s.chatUserIDs(ctx, userID, peerID, peerType)
+	if err != nil {
+		return err
+	}
+
+	// Current user doesn't need to know about his own typing
+	s.centrifugoBroadcast(
+		ctx,
+		util.Map(lo.Without(chatUserIDs, userID), chatdomain.ChannelTypingUpdates),
+		chatdomain.UpdateChatTypingNotification{
 			UserID:   userID,
 			PeerID:   peerID,
 			PeerType: peerType,
+			Typing:   typing,
+		},
+	)
+
+	return nil
+}
+
+func (s *Service) checkPeer(ctx context.Context, userID, peerID, peerType string) error {
+	switch peerType {
+	case chatdomain.PeerTypeUser:
+		exists, err := s.userRepository.Exists(ctx, peerID)
+		if err != nil {
+			return err
 		}
 
-		// Publishing for current user
-		s.centrifugoPublish(ctx, chatdomain.ChannelReadUpdates(userID), data)
+		if !exists {
+			return userdomain.ErrUserNotFound()
+		}
 
-		// Publishing for peer
-		s.centrifugoPublish(ctx, chatdomain.ChannelReadUpdates(peerID), data)
+	case chatdomain.PeerTypeGroup:
+		// Groups are visible only for their members
+		_, err := s.groupRepository.GetMember(ctx, peerID, userID)
+		if repository.IsNoDocumentsErr(err) {
+			return chatdomain.ErrGroupNotFound()
+		}
+
+		if err != nil {
+			return err
+		}
 
 	default:
 		return domain.ErrNotImplemented()
@@ -215,23 +755,199 @@ func (s *Service) UpdateChatRead(ctx context.Context, userID, peerID, peerType s
 	return nil
 }
 
-func (s *Service) UpdateChatTyping(ctx context.Context, userID, peerID, peerType string, typing bool) error {
-	if err := s.checkPeer(ctx, peerID, peerType); err != nil {
+// prepareMessage checks access to chat and referenced entities and builds new message
+func (s *Service) prepareMessage(
+	ctx context.Context,
+	userID, peerID, peerType, text string,
+	attachmentIDs []string,
+	poll *chatdomain.Poll,
+	replyToID, threadRootID string,
+) (chatdomain.Message, error) {
+	if err := s.checkPeer(ctx, userID, peerID, peerType); err != nil {
+		return chatdomain.Message{}, err
+	}
+
+	message := newMessage(userID, peerID, peerType, text)
+
+	if poll != nil {
+		message.Kind = chatdomain.MessageKindPoll
+		message.Text = poll.Question
+		message.Poll = poll
+	}
+
+	if len(attachmentIDs) > 0 {
+		// Only own files can be attached
+		files, err := s.fileService.GetUserFiles(ctx, userID, attachmentIDs)
+		if err != nil {
+			return chatdomain.Message{}, err
+		}
+
+		message.Attachments = util.Map(files, chatdomain.NewAttachment)
+	}
+
+	if replyToID != "" {
+		replyTo, err := s.getReferencedMessage(ctx, userID, message.ChatID, replyToID)
+		if err != nil {
+			return chatdomain.Message{}, err
+		}
+
+		message.ReplyTo = chatdomain.NewMessagePreview(replyTo)
+	}
+
+	if threadRootID != "" {
+		threadRoot, err := s.getReferencedMessage(ctx, userID, message.ChatID, threadRootID)
+		if err != nil {
+			return chatdomain.Message{}, err
+		}
+
+		// Nested threads are not supported
+		if threadRoot.ThreadRootID != "" {
+			return chatdomain.Message{}, chatdomain.ErrMessageReferenceInvalid()
+		}
+
+		message.ThreadRootID = threadRootID
+	}
+
+	return message, nil
+}
+
+// createMessage saves message and notifies all chat participants
+func (s *Service) createMessage(ctx context.Context, message *chatdomain.Message) error {
+	chatUserIDs, err := s.chatUserIDs(ctx, message.UserID, message.PeerID, message.PeerType)
+	if err != nil {
 		return err
 	}
 
-	switch peerType {
-	case chatdomain.PeerTypeUser:
-		s.centrifugoPublish(
+	ttl, err := s.getChatTTL(ctx, message.ChatID)
+	if err != nil {
+		return err
+	}
+
+	if ttl.TTL > 0 {
+		message.ExpireAt = lo.ToPtr(message.CreatedAt.Add(ttl.TTL))
+	}
+
+	// Forwarded text was written by another user, so its mentions are not resolved
+	if message.ForwardedFrom == nil {
+		message.MentionUserIDs, err = s.resolveMentions(ctx, message.UserID, message.Text, chatUserIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Message with the same id was already sent, for example scheduled message after restart
+	inserted, err := s.messageRepository.Create(ctx, message)
+	if repository.IsFilesDeletedErr(err) {
+		// Attachments were deleted after they had been checked
+		return filedomain.ErrFileNotFound()
+	}
+
+	if err != nil || !inserted {
+		return err
+	}
+
+	mutedUserIDs, err := s.chatRepository.ListMutedUserIDs(ctx, message.ChatID, message.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	// Muted chats still notify about mentions
+	silentUserIDs := lo.Without(lo.Intersect(chatUserIDs, mutedUserIDs), message.MentionUserIDs...)
+
+	for silent, userIDs := range map[bool][]string{
+		false: lo.Without(chatUserIDs, silentUserIDs...),
+		true:  silentUserIDs,
+	} {
+		s.centrifugoBroadcast(
+			ctx,
+			util.Map(userIDs, chatdomain.ChannelMessageNew),
+			chatdomain.NewMessageNotification{
+				MessageDTO: chatdomain.MapMessageDTO(*message),
+				Silent:     silent,
+			},
+		)
+	}
+
+	s.publishMentions(ctx, *message, message.MentionUserIDs)
+
+	if message.ThreadRootID != "" {
+		threadRoot, err := s.messageRepository.UpdateThread(ctx, message.ThreadRootID, message.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		// Updating replies count in thread root
+		s.centrifugoBroadcast(
 			ctx,
-			chatdomain.ChannelTypingUpdates(peerID),
-			chatdomain.UpdateChatTypingNotification{
-				UserID:   userID,
-				PeerID:   peerID,
-				PeerType: peerType,
-				Typing:   typing,
+			util.Map(chatUserIDs, chatdomain.ChannelMessageUpdates),
+			chatdomain.UpdateMessageNotification{
+				MessageDTO: chatdomain.MapMessageDTO(threadRoot),
 			},
 		)
+	}
+
+	return nil
+}
+
+// getMessage returns message, visible for user
+func (s *Service) getMessage(ctx context.Context, userID, id string) (chatdomain.Message, error) {
+	message, err := s.messageRepository.Get(ctx, id)
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.Message{}, chatdomain.ErrMessageNotFound()
+	}
+
+	if err != nil {
+		return chatdomain.Message{}, err
+	}
+
+	if err := s.checkMessageAccess(ctx, userID, message); err != nil {
+		return chatdomain.Message{}, err
+	}
+
+	// Message was deleted by current user
+	if lo.Contains(message.DeletedUserIDs, userID) {
+		return chatdomain.Message{}, chatdomain.ErrMessageNotFound()
+	}
+
+	return message, nil
+}
+
+// getReferencedMessage returns message, which can be quoted or used as a thread root in provided chat
+func (s *Service) getReferencedMessage(ctx context.Context, userID, chatID, id string) (chatdomain.Message, error) {
+	message, err := s.messageRepository.Get(ctx, id)
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.Message{}, chatdomain.ErrMessageReferenceInvalid()
+	}
+
+	if err != nil {
+		return chatdomain.Message{}, err
+	}
+
+	if message.ChatID != chatID || message.Deleted || lo.Contains(message.DeletedUserIDs, userID) {
+		return chatdomain.Message{}, chatdomain.ErrMessageReferenceInvalid()
+	}
+
+	return message, nil
+}
+
+func (s *Service) checkMessageAccess(ctx context.Context, userID string, message chatdomain.Message) error {
+	switch message.PeerType {
+	case chatdomain.PeerTypeUser:
+		// Checking that user is sender or recipient of this message
+		if message.UserID != userID && message.PeerID != userID {
+			return domain.ErrForbidden()
+		}
+
+	case chatdomain.PeerTypeGroup:
+		// Checking that user is member of this group
+		_, err := s.groupRepository.GetMember(ctx, message.ChatID, userID)
+		if repository.IsNoDocumentsErr(err) {
+			return domain.ErrForbidden()
+		}
+
+		if err != nil {
+			return err
+		}
 
 	default:
 		return domain.ErrNotImplemented()
@@ -240,32 +956,64 @@ func (s *Service) UpdateChatTyping(ctx context.Context, userID, peerID, peerType
 	return nil
 }
 
-func (s *Service) checkPeer(ctx context.Context, peerID, peerType string) error {
+// chatUserIDs returns ids of all chat participants including current user
+func (s *Service) chatUserIDs(ctx context.Context, userID, peerID, peerType string) ([]string, error) {
 	switch peerType {
 	case chatdomain.PeerTypeUser:
-		exists, err := s.userRepository.Exists(ctx, peerID)
+		return lo.Uniq([]string{userID, peerID}), nil
+
+	case chatdomain.PeerTypeGroup:
+		members, err := s.groupRepository.ListMembers(ctx, peerID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		if !exists {
-			return userdomain.ErrUserNotFound()
-		}
+		return util.Map(members, func(member chatdomain.GroupMember) string {
+			return member.UserID
+		}), nil
 
 	default:
-		return domain.ErrNotImplemented()
+		return nil, domain.ErrNotImplemented()
 	}
+}
 
-	return nil
+func newMessage(userID, peerID, peerType, text string) chatdomain.Message {
+	now := time.Now()
+
+	return chatdomain.Message{
+		ID:        domain.ID(),
+		UserID:    userID,
+		PeerID:    peerID,
+		PeerType:  peerType,
+		ChatID:    chatdomain.ChatID(userID, peerID, peerType),
+		Kind:      chatdomain.MessageKindText,
+		Text:      text,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func mapDeleteMessageNotification(message chatdomain.Message, forEveryone bool) chatdomain.DeleteMessageNotification {
+	return chatdomain.DeleteMessageNotification{
+		ID:          message.ID,
+		UserID:      message.UserID,
+		PeerID:      message.PeerID,
+		PeerType:    message.PeerType,
+		ForEveryone: forEveryone,
+	}
 }
 
-func (s *Service) centrifugoPublish(ctx context.Context, channel string, data any) {
-	if _, err := s.centrifugoClient.Publish(ctx, channel, data); err != nil {
+func (s *Service) centrifugoBroadcast(ctx context.Context, channels []string, data any) {
+	if len(channels) == 0 {
+		return
+	}
+
+	if _, err := s.centrifugoClient.Broadcast(ctx, channels, data); err != nil {
 		zaplog.
 			UnpackLogger(ctx).
 			Warn(
-				"centrifugo publish error",
-				zap.String("channel", channel),
+				"centrifugo broadcast error",
+				zap.Strings("channels", channels),
 				zap.Error(err),
 			)
 	}
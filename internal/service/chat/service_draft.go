@@ -0,0 +1,98 @@
+package chatservice
+
+import (
+	"context"
+	"time"
+
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+)
+
+func (s *Service) UpdateDraft(ctx context.Context, userID, peerID, peerType, text, replyToID string) error {
+	if err := s.checkPeer(ctx, userID, peerID, peerType); err != nil {
+		return err
+	}
+
+	draft, err := s.draftRepository.Update(ctx, chatdomain.Draft{
+		ChatID:    chatdomain.ChatID(userID, peerID, peerType),
+		UserID:    userID,
+		PeerID:    peerID,
+		PeerType:  peerType,
+		Text:      text,
+		ReplyToID: replyToID,
+		UpdatedAt: time.Now(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	s.publishDraftUpdate(ctx, userID, peerID, peerType, &draft)
+
+	return nil
+}
+
+func (s *Service) GetDraft(ctx context.Context, userID, peerID, peerType string) (chatdomain.DraftDTO, error) {
+	if err := s.checkPeer(ctx, userID, peerID, peerType); err != nil {
+		return chatdomain.DraftDTO{}, err
+	}
+
+	draft, err := s.draftRepository.Get(ctx, chatdomain.ChatID(userID, peerID, peerType), userID)
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.DraftDTO{}, chatdomain.ErrDraftNotFound()
+	}
+
+	if err != nil {
+		return chatdomain.DraftDTO{}, err
+	}
+
+	return chatdomain.MapDraftDTO(draft), nil
+}
+
+func (s *Service) DeleteDraft(ctx context.Context, userID, peerID, peerType string) error {
+	if err := s.checkPeer(ctx, userID, peerID, peerType); err != nil {
+		return err
+	}
+
+	deleted, err := s.deleteDraft(ctx, userID, peerID, peerType)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return chatdomain.ErrDraftNotFound()
+	}
+
+	return nil
+}
+
+// deleteDraft deletes draft and notifies other sessions of user
+func (s *Service) deleteDraft(ctx context.Context, userID, peerID, peerType string) (bool, error) {
+	deleted, err := s.draftRepository.Delete(ctx, chatdomain.ChatID(userID, peerID, peerType), userID)
+	if err != nil || !deleted {
+		return false, err
+	}
+
+	s.publishDraftUpdate(ctx, userID, peerID, peerType, nil)
+
+	return true, nil
+}
+
+func (s *Service) publishDraftUpdate(ctx context.Context, userID, peerID, peerType string, draft *chatdomain.Draft) {
+	notification := chatdomain.UpdateDraftNotification{
+		PeerID:   peerID,
+		PeerType: peerType,
+	}
+
+	if draft != nil {
+		draftDTO := chatdomain.MapDraftDTO(*draft)
+		notification.Draft = &draftDTO
+	}
+
+	// Drafts are private, so only sessions of user are notified
+	s.centrifugoBroadcast(
+		ctx,
+		[]string{chatdomain.ChannelDraftUpdates(userID)},
+		notification,
+	)
+}
@@ -0,0 +1,225 @@
+package chatservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/fx"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	zaplog "github.com/undefined7887/harmony-backend/internal/infrastructure/log/zap"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+	"github.com/undefined7887/harmony-backend/internal/util"
+)
+
+func NewMessageReaperRunner(lifecycle fx.Lifecycle, logger *zap.Logger, service *Service) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		done        = make(chan struct{})
+	)
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			logger.Info("starting background messages reaper")
+
+			go func() {
+				defer close(done)
+
+				service.BackgroundReapMessages(zaplog.PackLogger(ctx, logger), logger)
+			}()
+
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			logger.Info("stopping background messages reaper")
+
+			cancel()
+
+			select {
+			case <-done:
+				return nil
+
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+func (s *Service) GetChatTTL(ctx context.Context, userID, peerID, peerType string) (chatdomain.ChatTTLDTO, error) {
+	if err := s.checkPeer(ctx, userID, peerID, peerType); err != nil {
+		return chatdomain.ChatTTLDTO{}, err
+	}
+
+	ttl, err := s.getChatTTL(ctx, chatdomain.ChatID(userID, peerID, peerType))
+	if err != nil {
+		return chatdomain.ChatTTLDTO{}, err
+	}
+
+	return chatdomain.MapChatTTLDTO(ttl), nil
+}
+
+// UpdateChatTTL changes lifetime of new messages in chat, already sent messages are not affected
+func (s *Service) UpdateChatTTL(ctx context.Context, userID, peerID, peerType string, ttl time.Duration) error {
+	// Same as other group settings, lifetime in groups is changed only by admins
+	if peerType == chatdomain.PeerTypeGroup {
+		if _, err := s.checkGroupAdmin(ctx, peerID, userID); err != nil {
+			return err
+		}
+	} else if err := s.checkPeer(ctx, userID, peerID, peerType); err != nil {
+		return err
+	}
+
+	if ttl != 0 && !lo.Contains(s.config.MessageTTLs, ttl) {
+		return chatdomain.ErrMessageTTLInvalid()
+	}
+
+	chatTTL, err := s.chatRepository.UpdateTTL(ctx, chatdomain.ChatTTL{
+		ID:        chatdomain.ChatID(userID, peerID, peerType),
+		TTL:       ttl,
+		UserID:    userID,
+		UpdatedAt: time.Now(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	chatUserIDs, err := s.chatUserIDs(ctx, userID, peerID, peerType)
+	if err != nil {
+		return err
+	}
+
+	s.centrifugoBroadcast(
+		ctx,
+		util.Map(chatUserIDs, chatdomain.ChannelTTLUpdates),
+		chatdomain.UpdateChatTTLNotification{
+			PeerID:     peerID,
+			PeerType:   peerType,
+			ChatTTLDTO: chatdomain.MapChatTTLDTO(chatTTL),
+		},
+	)
+
+	return nil
+}
+
+// getChatTTL returns lifetime of messages in chat, zero lifetime is returned if it was never set
+func (s *Service) getChatTTL(ctx context.Context, chatID string) (chatdomain.ChatTTL, error) {
+	ttl, err := s.chatRepository.GetTTL(ctx, chatID)
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.ChatTTL{ID: chatID}, nil
+	}
+
+	return ttl, err
+}
+
+// BackgroundReapMessages deletes expired messages until context is cancelled
+func (s *Service) BackgroundReapMessages(ctx context.Context, logger *zap.Logger) {
+	ticker := time.NewTicker(s.config.ReaperInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.reapMessages(ctx); err != nil && ctx.Err() == nil {
+				logger.Warn("background reap messages error", zap.Error(err))
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reapMessages deletes expired messages and cleans up their unused attachments
+func (s *Service) reapMessages(ctx context.Context) error {
+	// Cleanups are retried even if messages were not reaped
+	return multierr.Combine(
+		s.reapExpiredMessages(ctx),
+		s.cleanupFiles(ctx),
+	)
+}
+
+// reapExpiredMessages deletes expired messages in batches and notifies participants
+func (s *Service) reapExpiredMessages(ctx context.Context) error {
+	for ctx.Err() == nil {
+		now := time.Now()
+
+		messages, err := s.messageRepository.ListExpired(ctx, now, s.config.ReaperBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			if err := s.reapMessage(ctx, message, now); err != nil {
+				return err
+			}
+		}
+
+		if int64(len(messages)) < s.config.ReaperBatchSize {
+			return nil
+		}
+	}
+
+	return ctx.Err()
+}
+
+func (s *Service) reapMessage(ctx context.Context, message chatdomain.Message, now time.Time) error {
+	deleted, err := s.messageRepository.DeleteExpired(ctx, message, now)
+	if err != nil || !deleted {
+		return err
+	}
+
+	chatUserIDs, err := s.chatUserIDs(ctx, message.UserID, message.PeerID, message.PeerType)
+	if err != nil {
+		return err
+	}
+
+	s.centrifugoBroadcast(
+		ctx,
+		util.Map(chatUserIDs, chatdomain.ChannelMessageDeletes),
+		mapDeleteMessageNotification(message, true),
+	)
+
+	return nil
+}
+
+// cleanupFiles deletes attachments of erased messages in batches, if they are not used anymore
+func (s *Service) cleanupFiles(ctx context.Context) error {
+	for ctx.Err() == nil {
+		cleanups, err := s.messageRepository.ListFileCleanups(ctx, s.config.ReaperBatchSize)
+		if err != nil || len(cleanups) == 0 {
+			return err
+		}
+
+		fileIDs := util.Map(cleanups, func(cleanup chatdomain.FileCleanup) string {
+			return cleanup.ID
+		})
+
+		// Forwarded copies of message share the same files, claimed files can't be attached concurrently
+		unusedFileIDs, err := s.messageRepository.ClaimFileCleanups(ctx, fileIDs)
+		if err != nil {
+			return err
+		}
+
+		if len(unusedFileIDs) > 0 {
+			if err := s.fileService.DeleteFiles(ctx, unusedFileIDs); err != nil {
+				return err
+			}
+
+			// Cleanups are kept until files are deleted, so deleting is retried on failures
+			if _, err := s.messageRepository.DeleteFileCleanups(ctx, unusedFileIDs); err != nil {
+				return err
+			}
+		}
+
+		if int64(len(cleanups)) < s.config.ReaperBatchSize {
+			return nil
+		}
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,83 @@
+package chatservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	filedomain "github.com/undefined7887/harmony-backend/internal/domain/file"
+)
+
+// fakeCleanupRepository keeps pending file cleanups in memory
+type fakeCleanupRepository struct {
+	*fakeMessageRepository
+
+	cleanups    []chatdomain.FileCleanup
+	usedFileIDs []string
+}
+
+func (f *fakeCleanupRepository) ListFileCleanups(_ context.Context, limit int64) ([]chatdomain.FileCleanup, error) {
+	if int64(len(f.cleanups)) > limit {
+		return f.cleanups[:limit], nil
+	}
+
+	return f.cleanups, nil
+}
+
+func (f *fakeCleanupRepository) DeleteFileCleanups(_ context.Context, ids []string) (int64, error) {
+	count := len(f.cleanups)
+
+	f.cleanups = lo.Reject(f.cleanups, func(cleanup chatdomain.FileCleanup, _ int) bool {
+		return lo.Contains(ids, cleanup.ID)
+	})
+
+	return int64(count - len(f.cleanups)), nil
+}
+
+func (f *fakeCleanupRepository) ClaimFileCleanups(ctx context.Context, ids []string) ([]string, error) {
+	usedIDs := lo.Intersect(ids, f.usedFileIDs)
+
+	if _, err := f.DeleteFileCleanups(ctx, usedIDs); err != nil {
+		return nil, err
+	}
+
+	return lo.Without(ids, usedIDs...), nil
+}
+
+func TestCleanupFiles(t *testing.T) {
+	var files []filedomain.File
+
+	for i := 0; i < 5; i++ {
+		files = append(files, filedomain.File{ID: domain.ID()})
+	}
+
+	fileService, fileRepository := newTestFileService(t, files...)
+
+	repository := &fakeCleanupRepository{
+		fakeMessageRepository: newFakeMessageRepository(),
+
+		// Last file is still attached to forwarded copy of message
+		usedFileIDs: []string{files[4].ID},
+	}
+
+	for _, file := range files {
+		repository.cleanups = append(repository.cleanups, chatdomain.FileCleanup{ID: file.ID})
+	}
+
+	service := &Service{
+		config:            &config.Chat{ReaperBatchSize: 2},
+		messageRepository: repository,
+		fileService:       fileService,
+	}
+
+	require.NoError(t, service.cleanupFiles(context.Background()))
+
+	assert.Empty(t, repository.cleanups)
+	assert.Equal(t, []string{files[4].ID}, lo.Keys(fileRepository.files))
+}
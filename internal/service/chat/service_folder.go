@@ -0,0 +1,191 @@
+package chatservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+	"github.com/undefined7887/harmony-backend/internal/util"
+)
+
+func (s *Service) CreateFolder(ctx context.Context, userID string, folder chatdomain.Folder) (string, error) {
+	now := time.Now()
+
+	folder = normalizeFolder(folder)
+	folder.ID = domain.ID()
+	folder.UserID = userID
+	folder.CreatedAt = now
+	folder.UpdatedAt = now
+
+	_, err := s.folderRepository.Create(ctx, &folder, s.config.FoldersLimit)
+	if repository.IsLimitExceededErr(err) {
+		return "", chatdomain.ErrFoldersLimitExceeded()
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return folder.ID, nil
+}
+
+func (s *Service) ListFolders(ctx context.Context, userID string) ([]chatdomain.FolderDTO, error) {
+	folders, err := s.folderRepository.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Map(folders, chatdomain.MapFolderDTO), nil
+}
+
+func (s *Service) UpdateFolder(ctx context.Context, userID, id string, folder chatdomain.Folder) error {
+	folder = normalizeFolder(folder)
+	folder.ID = id
+	folder.UserID = userID
+	folder.UpdatedAt = time.Now()
+
+	_, err := s.folderRepository.Update(ctx, folder)
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.ErrFolderNotFound()
+	}
+
+	return err
+}
+
+func (s *Service) DeleteFolder(ctx context.Context, userID, id string) error {
+	deleted, err := s.folderRepository.Delete(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return chatdomain.ErrFolderNotFound()
+	}
+
+	return nil
+}
+
+// ListFoldersUnread returns unread totals of every user folder
+func (s *Service) ListFoldersUnread(ctx context.Context, userID string) ([]chatdomain.FolderUnreadDTO, error) {
+	folders, err := s.folderRepository.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := s.newFolderResolver(userID)
+
+	filters := make([]chatdomain.FolderFilter, 0, len(folders))
+
+	for _, folder := range folders {
+		filter, err := resolver.resolve(ctx, folder)
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, filter)
+	}
+
+	unread, err := s.chatRepository.CountFoldersUnread(ctx, userID, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(folders, func(folder chatdomain.Folder, i int) chatdomain.FolderUnreadDTO {
+		return chatdomain.MapFolderUnreadDTO(folder, unread[i])
+	}), nil
+}
+
+// getFolderFilter returns filter of user folder for chat list
+func (s *Service) getFolderFilter(ctx context.Context, userID, id string) (*chatdomain.FolderFilter, error) {
+	folder, err := s.folderRepository.Get(ctx, id, userID)
+	if repository.IsNoDocumentsErr(err) {
+		return nil, chatdomain.ErrFolderNotFound()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := s.newFolderResolver(userID).resolve(ctx, folder)
+	if err != nil {
+		return nil, err
+	}
+
+	return &filter, nil
+}
+
+func normalizeFolder(folder chatdomain.Folder) chatdomain.Folder {
+	folder.Chats = lo.UniqBy(folder.Chats, func(chat chatdomain.FolderChat) string {
+		return chat.PeerID
+	})
+
+	folder.PeerTypes = lo.Uniq(folder.PeerTypes)
+	folder.UserIDs = lo.Uniq(folder.UserIDs)
+
+	return folder
+}
+
+// folderResolver converts folder rules to filters, caching groups of users between folders
+type folderResolver struct {
+	service *Service
+	userID  string
+
+	groupIDs map[string][]string
+}
+
+func (s *Service) newFolderResolver(userID string) *folderResolver {
+	return &folderResolver{
+		service:  s,
+		userID:   userID,
+		groupIDs: map[string][]string{},
+	}
+}
+
+func (r *folderResolver) resolve(ctx context.Context, folder chatdomain.Folder) (chatdomain.FolderFilter, error) {
+	peerIDs := util.Map(folder.Chats, func(chat chatdomain.FolderChat) string {
+		return chat.PeerID
+	})
+
+	if len(folder.UserIDs) > 0 {
+		userGroupIDs, err := r.userGroupIDs(ctx, r.userID)
+		if err != nil {
+			return chatdomain.FolderFilter{}, err
+		}
+
+		for _, folderUserID := range folder.UserIDs {
+			folderUserGroupIDs, err := r.userGroupIDs(ctx, folderUserID)
+			if err != nil {
+				return chatdomain.FolderFilter{}, err
+			}
+
+			// User chat and groups shared with current user
+			peerIDs = append(peerIDs, folderUserID)
+			peerIDs = append(peerIDs, lo.Intersect(userGroupIDs, folderUserGroupIDs)...)
+		}
+	}
+
+	return chatdomain.FolderFilter{
+		PeerIDs:   lo.Uniq(peerIDs),
+		PeerTypes: folder.PeerTypes,
+		Unread:    folder.Unread,
+	}, nil
+}
+
+func (r *folderResolver) userGroupIDs(ctx context.Context, userID string) ([]string, error) {
+	if groupIDs, ok := r.groupIDs[userID]; ok {
+		return groupIDs, nil
+	}
+
+	groupIDs, err := r.service.groupRepository.ListUserGroupIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.groupIDs[userID] = groupIDs
+
+	return groupIDs, nil
+}
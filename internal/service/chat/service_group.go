@@ -0,0 +1,427 @@
+package chatservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	userdomain "github.com/undefined7887/harmony-backend/internal/domain/user"
+	zaplog "github.com/undefined7887/harmony-backend/internal/infrastructure/log/zap"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+	"github.com/undefined7887/harmony-backend/internal/util"
+)
+
+func (s *Service) CreateGroup(ctx context.Context, userID, name string, userIDs []string) (chatdomain.GroupDTO, error) {
+	// Creator is always a member of group
+	userIDs = lo.Without(lo.Uniq(userIDs), userID)
+
+	for _, memberID := range userIDs {
+		if err := s.checkUser(ctx, memberID); err != nil {
+			return chatdomain.GroupDTO{}, err
+		}
+	}
+
+	now := time.Now()
+
+	group := chatdomain.Group{
+		ID:        domain.ID(),
+		UserID:    userID,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	members := make([]chatdomain.GroupMember, 0, len(userIDs)+1)
+
+	// Creator becomes owner
+	members = append(members, newGroupMember(group.ID, userID, chatdomain.GroupRoleOwner, now))
+
+	for _, memberID := range userIDs {
+		members = append(members, newGroupMember(group.ID, memberID, chatdomain.GroupRoleMember, now))
+	}
+
+	if _, err := s.groupRepository.Create(ctx, &group, members); err != nil {
+		return chatdomain.GroupDTO{}, err
+	}
+
+	return chatdomain.MapGroupDTO(group), nil
+}
+
+func (s *Service) GetGroup(ctx context.Context, userID, id string) (chatdomain.GroupDTO, error) {
+	if err := s.checkPeer(ctx, userID, id, chatdomain.PeerTypeGroup); err != nil {
+		return chatdomain.GroupDTO{}, err
+	}
+
+	group, err := s.groupRepository.Get(ctx, id)
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.GroupDTO{}, chatdomain.ErrGroupNotFound()
+	}
+
+	if err != nil {
+		return chatdomain.GroupDTO{}, err
+	}
+
+	return chatdomain.MapGroupDTO(group), nil
+}
+
+func (s *Service) UpdateGroup(ctx context.Context, userID, id, name string) (chatdomain.GroupDTO, error) {
+	if _, err := s.checkGroupAdmin(ctx, id, userID); err != nil {
+		return chatdomain.GroupDTO{}, err
+	}
+
+	group, err := s.groupRepository.UpdateName(ctx, id, name)
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.GroupDTO{}, chatdomain.ErrGroupNotFound()
+	}
+
+	if err != nil {
+		return chatdomain.GroupDTO{}, err
+	}
+
+	return chatdomain.MapGroupDTO(group), nil
+}
+
+func (s *Service) ListGroupMembers(ctx context.Context, userID, id string) ([]chatdomain.GroupMemberDTO, error) {
+	if err := s.checkPeer(ctx, userID, id, chatdomain.PeerTypeGroup); err != nil {
+		return nil, err
+	}
+
+	members, err := s.groupRepository.ListMembers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Map(members, chatdomain.MapGroupMemberDTO), nil
+}
+
+func (s *Service) AddGroupMember(ctx context.Context, userID, id, memberID string) error {
+	if _, err := s.checkGroupAdmin(ctx, id, userID); err != nil {
+		return err
+	}
+
+	if err := s.checkUser(ctx, memberID); err != nil {
+		return err
+	}
+
+	member := newGroupMember(id, memberID, chatdomain.GroupRoleMember, time.Now())
+
+	inserted, err := s.groupRepository.AddMember(ctx, &member)
+	if err != nil {
+		return err
+	}
+
+	if !inserted {
+		return chatdomain.ErrGroupMemberAlreadyExists()
+	}
+
+	s.publishGroupMemberUpdate(ctx, userID, chatdomain.GroupActionAdded, member)
+
+	return nil
+}
+
+func (s *Service) RemoveGroupMember(ctx context.Context, userID, id, memberID string) error {
+	actor, err := s.checkGroupAdmin(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	// Leaving group is a separate action
+	if userID == memberID {
+		return domain.ErrForbidden()
+	}
+
+	member, err := s.getGroupMember(ctx, id, memberID)
+	if err != nil {
+		return err
+	}
+
+	// Only owner can remove admins, owner can't be removed at all
+	if chatdomain.IsGroupAdmin(member.Role) && actor.Role != chatdomain.GroupRoleOwner {
+		return domain.ErrForbidden()
+	}
+
+	deleted, err := s.groupRepository.DeleteMember(ctx, id, memberID)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return chatdomain.ErrGroupMemberNotFound()
+	}
+
+	s.publishGroupMemberUpdate(ctx, userID, chatdomain.GroupActionRemoved, member)
+
+	return nil
+}
+
+func (s *Service) LeaveGroup(ctx context.Context, userID, id string) error {
+	member, err := s.getGroupMember(ctx, id, userID)
+	if domain.IsError(err, chatdomain.ErrGroupMemberNotFound()) {
+		return chatdomain.ErrGroupNotFound()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if member.Role == chatdomain.GroupRoleOwner {
+		return chatdomain.ErrGroupOwnerCannotLeave()
+	}
+
+	deleted, err := s.groupRepository.DeleteMember(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return chatdomain.ErrGroupNotFound()
+	}
+
+	s.publishGroupMemberUpdate(ctx, userID, chatdomain.GroupActionLeft, member)
+
+	return nil
+}
+
+func (s *Service) UpdateGroupMemberRole(ctx context.Context, userID, id, memberID, role string) error {
+	if _, err := s.checkGroupRole(ctx, id, userID, chatdomain.GroupRoleOwner); err != nil {
+		return err
+	}
+
+	member, err := s.groupRepository.UpdateMemberRole(ctx, id, memberID, role)
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.ErrGroupMemberNotFound()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s.publishGroupMemberUpdate(ctx, userID, chatdomain.GroupActionRoleUpdated, member)
+
+	return nil
+}
+
+func (s *Service) TransferGroupOwnership(ctx context.Context, userID, id, memberID string) error {
+	owner, err := s.checkGroupRole(ctx, id, userID, chatdomain.GroupRoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if userID == memberID {
+		return domain.ErrForbidden()
+	}
+
+	transferred, err := s.groupRepository.TransferOwnership(ctx, id, userID, memberID)
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.ErrGroupMemberNotFound()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Ownership was changed concurrently
+	if !transferred {
+		return domain.ErrForbidden()
+	}
+
+	newOwner, err := s.getGroupMember(ctx, id, memberID)
+	if err != nil {
+		return err
+	}
+
+	owner.Role = chatdomain.GroupRoleAdmin
+
+	s.publishGroupMemberUpdate(ctx, userID, chatdomain.GroupActionRoleUpdated, owner)
+	s.publishGroupMemberUpdate(ctx, userID, chatdomain.GroupActionRoleUpdated, newOwner)
+
+	return nil
+}
+
+func (s *Service) CreateGroupInvite(
+	ctx context.Context,
+	userID, id string,
+	lifetime time.Duration,
+	maxUses int64,
+) (chatdomain.GroupInviteDTO, error) {
+	if _, err := s.checkGroupAdmin(ctx, id, userID); err != nil {
+		return chatdomain.GroupInviteDTO{}, err
+	}
+
+	now := time.Now()
+
+	invite := chatdomain.GroupInvite{
+		ID:        domain.Token(),
+		GroupID:   id,
+		UserID:    userID,
+		MaxUses:   maxUses,
+		ExpireAt:  now.Add(lifetime),
+		CreatedAt: now,
+	}
+
+	if _, err := s.groupRepository.CreateInvite(ctx, &invite); err != nil {
+		return chatdomain.GroupInviteDTO{}, err
+	}
+
+	return chatdomain.MapGroupInviteDTO(invite), nil
+}
+
+func (s *Service) ListGroupInvites(ctx context.Context, userID, id string) ([]chatdomain.GroupInviteDTO, error) {
+	if _, err := s.checkGroupAdmin(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	invites, err := s.groupRepository.ListInvites(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(invites) == 0 {
+		return nil, chatdomain.ErrGroupInviteNotFound()
+	}
+
+	return util.Map(invites, chatdomain.MapGroupInviteDTO), nil
+}
+
+func (s *Service) DeleteGroupInvite(ctx context.Context, userID, id, token string) error {
+	if _, err := s.checkGroupAdmin(ctx, id, userID); err != nil {
+		return err
+	}
+
+	deleted, err := s.groupRepository.DeleteInvite(ctx, id, token)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return chatdomain.ErrGroupInviteNotFound()
+	}
+
+	return nil
+}
+
+func (s *Service) JoinGroup(ctx context.Context, userID, token string) (string, error) {
+	invite, err := s.groupRepository.GetInvite(ctx, token)
+	if repository.IsNoDocumentsErr(err) {
+		return "", chatdomain.ErrGroupInviteNotFound()
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	// Checking membership before using invite, so members don't waste invite uses
+	_, err = s.groupRepository.GetMember(ctx, invite.GroupID, userID)
+	if err == nil {
+		return "", chatdomain.ErrGroupMemberAlreadyExists()
+	}
+
+	if !repository.IsNoDocumentsErr(err) {
+		return "", err
+	}
+
+	member := newGroupMember(invite.GroupID, userID, chatdomain.GroupRoleMember, time.Now())
+
+	joined, err := s.groupRepository.Join(ctx, token, &member)
+	if repository.IsNoDocumentsErr(err) {
+		return "", chatdomain.ErrGroupInviteNotFound()
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if !joined {
+		return "", chatdomain.ErrGroupMemberAlreadyExists()
+	}
+
+	s.publishGroupMemberUpdate(ctx, userID, chatdomain.GroupActionJoined, member)
+
+	return invite.GroupID, nil
+}
+
+// checkGroupRole checks that user is a group member with one of provided roles
+func (s *Service) checkGroupRole(ctx context.Context, groupID, userID string, roles ...string) (chatdomain.GroupMember, error) {
+	member, err := s.getGroupMember(ctx, groupID, userID)
+
+	// Groups are visible only for their members
+	if domain.IsError(err, chatdomain.ErrGroupMemberNotFound()) {
+		return chatdomain.GroupMember{}, chatdomain.ErrGroupNotFound()
+	}
+
+	if err != nil {
+		return chatdomain.GroupMember{}, err
+	}
+
+	if !lo.Contains(roles, member.Role) {
+		return chatdomain.GroupMember{}, domain.ErrForbidden()
+	}
+
+	return member, nil
+}
+
+func (s *Service) checkGroupAdmin(ctx context.Context, groupID, userID string) (chatdomain.GroupMember, error) {
+	return s.checkGroupRole(ctx, groupID, userID, chatdomain.GroupRoleOwner, chatdomain.GroupRoleAdmin)
+}
+
+func (s *Service) getGroupMember(ctx context.Context, groupID, userID string) (chatdomain.GroupMember, error) {
+	member, err := s.groupRepository.GetMember(ctx, groupID, userID)
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.GroupMember{}, chatdomain.ErrGroupMemberNotFound()
+	}
+
+	if err != nil {
+		return chatdomain.GroupMember{}, err
+	}
+
+	return member, nil
+}
+
+func (s *Service) checkUser(ctx context.Context, userID string) error {
+	exists, err := s.userRepository.Exists(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return userdomain.ErrUserNotFound()
+	}
+
+	return nil
+}
+
+// publishGroupMemberUpdate notifies all group members and affected member about membership changes
+func (s *Service) publishGroupMemberUpdate(ctx context.Context, actorID, action string, member chatdomain.GroupMember) {
+	chatUserIDs, err := s.chatUserIDs(ctx, actorID, member.GroupID, chatdomain.PeerTypeGroup)
+	if err != nil {
+		zaplog.
+			UnpackLogger(ctx).
+			Warn("failed to list group members", zap.String("group_id", member.GroupID), zap.Error(err))
+	}
+
+	s.centrifugoBroadcast(
+		ctx,
+		util.Map(lo.Uniq(append(chatUserIDs, member.UserID)), chatdomain.ChannelGroupUpdates),
+		chatdomain.GroupMemberNotification{
+			GroupID: member.GroupID,
+			ActorID: actorID,
+			Action:  action,
+			Member:  chatdomain.MapGroupMemberDTO(member),
+		},
+	)
+}
+
+func newGroupMember(groupID, userID, role string, now time.Time) chatdomain.GroupMember {
+	return chatdomain.GroupMember{
+		ID:        domain.ID(),
+		GroupID:   groupID,
+		UserID:    userID,
+		Role:      role,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
@@ -0,0 +1,62 @@
+package chatservice
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	userdomain "github.com/undefined7887/harmony-backend/internal/domain/user"
+	"github.com/undefined7887/harmony-backend/internal/util"
+	"github.com/undefined7887/harmony-backend/internal/validation"
+)
+
+// resolveMentions returns ids of chat participants, mentioned in text as '@nickname#1234'.
+// Only the first nicknames up to mentions limit are resolved, unknown nicknames, non-participants and sender are skipped
+func (s *Service) resolveMentions(ctx context.Context, userID, text string, chatUserIDs []string) ([]string, error) {
+	nicknames := lo.Uniq(util.Map(validation.MentionRegexp.FindAllStringSubmatch(text, -1), func(match []string) string {
+		return match[1]
+	}))
+
+	if len(nicknames) == 0 {
+		return nil, nil
+	}
+
+	if int64(len(nicknames)) > s.config.MentionsLimit {
+		nicknames = nicknames[:s.config.MentionsLimit]
+	}
+
+	users, err := s.userRepository.ListByNicknames(ctx, nicknames)
+	if err != nil {
+		return nil, err
+	}
+
+	usersByNickname := lo.KeyBy(users, func(user userdomain.User) string {
+		return user.Nickname
+	})
+
+	var mentionUserIDs []string
+
+	// Keeping order of mentions in text
+	for _, nickname := range nicknames {
+		user, ok := usersByNickname[nickname]
+		if !ok || user.ID == userID || !lo.Contains(chatUserIDs, user.ID) {
+			continue
+		}
+
+		mentionUserIDs = append(mentionUserIDs, user.ID)
+	}
+
+	return mentionUserIDs, nil
+}
+
+// publishMentions notifies mentioned users, regardless of their chat settings
+func (s *Service) publishMentions(ctx context.Context, message chatdomain.Message, userIDs []string) {
+	s.centrifugoBroadcast(
+		ctx,
+		util.Map(userIDs, chatdomain.ChannelMentionNew),
+		chatdomain.NewMentionNotification{
+			MessageDTO: chatdomain.MapMessageDTO(message),
+		},
+	)
+}
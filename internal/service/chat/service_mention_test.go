@@ -0,0 +1,58 @@
+package chatservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+	userdomain "github.com/undefined7887/harmony-backend/internal/domain/user"
+)
+
+// fakeNicknameRepository finds users by nicknames and records queries, not implemented methods panic
+type fakeNicknameRepository struct {
+	userdomain.Repository
+
+	users   []userdomain.User
+	queries [][]string
+}
+
+func (f *fakeNicknameRepository) ListByNicknames(_ context.Context, nicknames []string) ([]userdomain.User, error) {
+	f.queries = append(f.queries, nicknames)
+
+	return lo.Filter(f.users, func(user userdomain.User, _ int) bool {
+		return lo.Contains(nicknames, user.Nickname)
+	}), nil
+}
+
+func TestResolveMentions(t *testing.T) {
+	userRepository := &fakeNicknameRepository{
+		users: []userdomain.User{
+			{ID: testSenderID, Nickname: "sender#0001"},
+			{ID: testRecipientID, Nickname: "recipient#0002"},
+			{ID: testStrangerID, Nickname: "stranger#0003"},
+		},
+	}
+
+	service := &Service{
+		config:         &config.Chat{MentionsLimit: 3},
+		userRepository: userRepository,
+	}
+
+	// Sender, stranger and unknown user are skipped, mentions over limit are not resolved
+	text := "@sender#0001 @stranger#0003 @unknown#0004 @recipient#0002 @recipient#0002"
+
+	userIDs, err := service.resolveMentions(context.Background(), testSenderID, text, []string{testSenderID, testRecipientID})
+	require.NoError(t, err)
+	assert.Empty(t, userIDs)
+
+	require.Len(t, userRepository.queries, 1)
+	assert.Equal(t, []string{"sender#0001", "stranger#0003", "unknown#0004"}, userRepository.queries[0])
+
+	userIDs, err = service.resolveMentions(context.Background(), testSenderID, "hi @recipient#0002", []string{testSenderID, testRecipientID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{testRecipientID}, userIDs)
+}
@@ -0,0 +1,162 @@
+package chatservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+	"github.com/undefined7887/harmony-backend/internal/util"
+)
+
+func (s *Service) PinMessage(ctx context.Context, userID, peerID, peerType, messageID string) error {
+	if err := s.checkPinAccess(ctx, userID, peerID, peerType); err != nil {
+		return err
+	}
+
+	chatID := chatdomain.ChatID(userID, peerID, peerType)
+
+	message, err := s.getMessage(ctx, userID, messageID)
+	if err != nil {
+		return err
+	}
+
+	// Only visible chat messages can be pinned
+	if message.ChatID != chatID || message.Deleted || message.ThreadRootID != "" {
+		return chatdomain.ErrMessageNotFound()
+	}
+
+	pin := chatdomain.Pin{
+		ID:        domain.ID(),
+		ChatID:    chatID,
+		MessageID: messageID,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}
+
+	inserted, err := s.pinRepository.Create(ctx, &pin, s.config.PinsLimit)
+	if repository.IsLimitExceededErr(err) {
+		return chatdomain.ErrPinsLimitExceeded()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !inserted {
+		return chatdomain.ErrMessageAlreadyPinned()
+	}
+
+	return s.publishPinUpdate(ctx, userID, peerID, peerType, messageID, true)
+}
+
+func (s *Service) UnpinMessage(ctx context.Context, userID, peerID, peerType, messageID string) error {
+	if err := s.checkPinAccess(ctx, userID, peerID, peerType); err != nil {
+		return err
+	}
+
+	deleted, err := s.pinRepository.Delete(ctx, chatdomain.ChatID(userID, peerID, peerType), messageID)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return chatdomain.ErrPinNotFound()
+	}
+
+	return s.publishPinUpdate(ctx, userID, peerID, peerType, messageID, false)
+}
+
+func (s *Service) ListPins(ctx context.Context, userID, peerID, peerType string) ([]chatdomain.PinDTO, error) {
+	if peerType == chatdomain.PeerTypeGroup {
+		// Only members can see group pins
+		if err := s.checkPeer(ctx, userID, peerID, peerType); err != nil {
+			return nil, err
+		}
+	}
+
+	chatID := chatdomain.ChatID(userID, peerID, peerType)
+
+	pins, err := s.pinRepository.List(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := s.messageRepository.ListByIDs(ctx, util.Map(pins, func(pin chatdomain.Pin) string {
+		return pin.MessageID
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	messagesByID := lo.KeyBy(messages, func(message chatdomain.Message) string {
+		return message.ID
+	})
+
+	readSummaries, err := s.chatRepository.ListReadSummaries(ctx, userID, []string{chatID})
+	if err != nil {
+		return nil, err
+	}
+
+	mapMessageDTO := chatdomain.MapUserMessageDTO(userID, readSummaries)
+
+	result := make([]chatdomain.PinDTO, 0, len(pins))
+
+	// Keeping pinning order
+	for _, pin := range pins {
+		message, ok := messagesByID[pin.MessageID]
+
+		// Skipping messages deleted by user
+		if !ok || lo.Contains(message.DeletedUserIDs, userID) {
+			continue
+		}
+
+		result = append(result, chatdomain.PinDTO{
+			UserID:    pin.UserID,
+			Message:   mapMessageDTO(message),
+			CreatedAt: pin.CreatedAt,
+		})
+	}
+
+	if len(result) == 0 {
+		return nil, chatdomain.ErrPinNotFound()
+	}
+
+	return result, nil
+}
+
+// checkPinAccess checks that user can pin messages in chat
+func (s *Service) checkPinAccess(ctx context.Context, userID, peerID, peerType string) error {
+	// Only admins can pin messages in groups
+	if peerType == chatdomain.PeerTypeGroup {
+		_, err := s.checkGroupAdmin(ctx, peerID, userID)
+
+		return err
+	}
+
+	return s.checkPeer(ctx, userID, peerID, peerType)
+}
+
+func (s *Service) publishPinUpdate(ctx context.Context, userID, peerID, peerType, messageID string, pinned bool) error {
+	chatUserIDs, err := s.chatUserIDs(ctx, userID, peerID, peerType)
+	if err != nil {
+		return err
+	}
+
+	s.centrifugoBroadcast(
+		ctx,
+		util.Map(chatUserIDs, chatdomain.ChannelPinUpdates),
+		chatdomain.UpdatePinNotification{
+			UserID:    userID,
+			PeerID:    peerID,
+			PeerType:  peerType,
+			MessageID: messageID,
+			Pinned:    pinned,
+		},
+	)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package chatservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+)
+
+// fakePinRepository keeps pins in memory, not implemented methods panic
+type fakePinRepository struct {
+	chatdomain.PinRepository
+
+	pins []chatdomain.Pin
+}
+
+func (f *fakePinRepository) List(_ context.Context, chatID string) ([]chatdomain.Pin, error) {
+	var result []chatdomain.Pin
+
+	for _, pin := range f.pins {
+		if pin.ChatID == chatID {
+			result = append(result, pin)
+		}
+	}
+
+	return result, nil
+}
+
+func TestListPinsReads(t *testing.T) {
+	message := newTestDirectMessage()
+
+	service := &Service{
+		messageRepository: newFakeMessageRepository(message),
+		pinRepository: &fakePinRepository{
+			pins: []chatdomain.Pin{
+				{ID: message.ID, ChatID: message.ChatID, MessageID: message.ID, UserID: testSenderID},
+			},
+		},
+		chatRepository: &fakeChatRepository{
+			reads: []chatdomain.ReadState{
+				{
+					ID:               chatdomain.ReadStateID(message.ChatID, testRecipientID),
+					ChatID:           message.ChatID,
+					UserID:           testRecipientID,
+					MessageID:        message.ID,
+					MessageCreatedAt: message.CreatedAt,
+					UpdatedAt:        time.Now(),
+				},
+			},
+		},
+	}
+
+	pins, err := service.ListPins(context.Background(), testSenderID, testRecipientID, chatdomain.PeerTypeUser)
+	require.NoError(t, err)
+	require.Len(t, pins, 1)
+
+	assert.True(t, pins[0].Message.Read)
+
+	// Read status is shown only to sender
+	pins, err = service.ListPins(context.Background(), testRecipientID, testSenderID, chatdomain.PeerTypeUser)
+	require.NoError(t, err)
+	require.Len(t, pins, 1)
+
+	assert.False(t, pins[0].Message.Read)
+}
@@ -0,0 +1,184 @@
+package chatservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/lo"
+
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+	"github.com/undefined7887/harmony-backend/internal/util"
+)
+
+// VotePoll replaces choice of user in poll, available for all chat participants
+func (s *Service) VotePoll(ctx context.Context, userID, id string, optionIDs []int) error {
+	message, err := s.getPoll(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if message.Poll.Closed(now) {
+		return chatdomain.ErrPollClosed()
+	}
+
+	optionIDs = lo.Uniq(optionIDs)
+
+	if len(optionIDs) > 1 && !message.Poll.MultipleChoice {
+		return chatdomain.ErrPollOptionsInvalid()
+	}
+
+	if !lo.EveryBy(optionIDs, message.Poll.HasOption) {
+		return chatdomain.ErrPollOptionsInvalid()
+	}
+
+	updatedMessage, err := s.messageRepository.Vote(ctx, chatdomain.PollVote{
+		ID:        chatdomain.PollVoteID(id, userID),
+		MessageID: id,
+		UserID:    userID,
+		OptionIDs: optionIDs,
+		CreatedAt: now,
+	})
+
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.ErrPollNotFound()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.publishVoteUpdate(ctx, updatedMessage, chatdomain.VoteUpdateDTO{
+		UserID:    userID,
+		OptionIDs: optionIDs,
+		Voted:     true,
+	})
+}
+
+// RetractPollVote deletes choice of user in poll, closed polls keep their votes
+func (s *Service) RetractPollVote(ctx context.Context, userID, id string) error {
+	message, err := s.getPoll(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if message.Poll.Closed(time.Now()) {
+		return chatdomain.ErrPollClosed()
+	}
+
+	// Poll was checked above, so only vote can be missing
+	updatedMessage, err := s.messageRepository.RetractVote(ctx, id, userID)
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.ErrPollVoteNotFound()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.publishVoteUpdate(ctx, updatedMessage, chatdomain.VoteUpdateDTO{
+		UserID: userID,
+		Voted:  false,
+	})
+}
+
+// ListPollVotes returns votes in poll, for anonymous polls only own vote is returned
+func (s *Service) ListPollVotes(ctx context.Context, userID, id string) ([]chatdomain.PollVoteDTO, error) {
+	message, err := s.getPoll(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.Poll.Anonymous {
+		vote, err := s.messageRepository.GetVote(ctx, id, userID)
+		if repository.IsNoDocumentsErr(err) {
+			return []chatdomain.PollVoteDTO{}, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []chatdomain.PollVoteDTO{chatdomain.MapPollVoteDTO(vote)}, nil
+	}
+
+	votes, err := s.messageRepository.ListVotes(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Map(votes, chatdomain.MapPollVoteDTO), nil
+}
+
+// getPoll returns poll message, visible for user
+func (s *Service) getPoll(ctx context.Context, userID, id string) (chatdomain.Message, error) {
+	message, err := s.getMessage(ctx, userID, id)
+	if err != nil {
+		return chatdomain.Message{}, err
+	}
+
+	if message.Deleted || message.Poll == nil {
+		return chatdomain.Message{}, chatdomain.ErrPollNotFound()
+	}
+
+	return message, nil
+}
+
+// checkPoll checks new poll, which is sent at provided time
+func (s *Service) checkPoll(poll *chatdomain.Poll, sendAt time.Time) error {
+	if poll == nil {
+		return nil
+	}
+
+	texts := util.Map(poll.Options, func(option chatdomain.PollOption) string {
+		return option.Text
+	})
+
+	if len(lo.Uniq(texts)) != len(texts) || poll.Closed(sendAt) {
+		return chatdomain.ErrPollInvalid()
+	}
+
+	return nil
+}
+
+// publishVoteUpdate notifies chat participants about new poll results,
+// voters of anonymous polls are visible only to themselves
+func (s *Service) publishVoteUpdate(ctx context.Context, message chatdomain.Message, vote chatdomain.VoteUpdateDTO) error {
+	chatUserIDs, err := s.chatUserIDs(ctx, message.UserID, message.PeerID, message.PeerType)
+	if err != nil {
+		return err
+	}
+
+	otherUserIDs := chatUserIDs
+
+	if message.Poll.Anonymous {
+		otherUserIDs = lo.Without(chatUserIDs, vote.UserID)
+
+		// Other sessions of voter are synced with full vote
+		s.centrifugoBroadcast(
+			ctx,
+			[]string{chatdomain.ChannelMessageUpdates(vote.UserID)},
+			chatdomain.UpdateMessageNotification{
+				MessageDTO: chatdomain.MapMessageDTO(message),
+				Vote:       &vote,
+			},
+		)
+
+		vote = chatdomain.VoteUpdateDTO{
+			Voted: vote.Voted,
+		}
+	}
+
+	s.centrifugoBroadcast(
+		ctx,
+		util.Map(otherUserIDs, chatdomain.ChannelMessageUpdates),
+		chatdomain.UpdateMessageNotification{
+			MessageDTO: chatdomain.MapMessageDTO(message),
+			Vote:       &vote,
+		},
+	)
+
+	return nil
+}
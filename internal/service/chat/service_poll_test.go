@@ -0,0 +1,102 @@
+package chatservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+)
+
+// fakePollRepository keeps votes of polls in memory
+type fakePollRepository struct {
+	*fakeMessageRepository
+
+	votes []chatdomain.PollVote
+}
+
+func (f *fakePollRepository) ListVotes(_ context.Context, id string) ([]chatdomain.PollVote, error) {
+	var result []chatdomain.PollVote
+
+	for _, vote := range f.votes {
+		if vote.MessageID == id {
+			result = append(result, vote)
+		}
+	}
+
+	return result, nil
+}
+
+func (f *fakePollRepository) GetVote(_ context.Context, id, userID string) (chatdomain.PollVote, error) {
+	for _, vote := range f.votes {
+		if vote.MessageID == id && vote.UserID == userID {
+			return vote, nil
+		}
+	}
+
+	return chatdomain.PollVote{}, mongo.ErrNoDocuments
+}
+
+// newTestPollService returns service with direct poll message, voted by both participants
+func newTestPollService(anonymous bool) (*Service, chatdomain.Message) {
+	message := newTestDirectMessage()
+	message.Kind = chatdomain.MessageKindPoll
+	message.Poll = chatdomain.NewPoll("question", []string{"yes", "no"}, false, anonymous, nil)
+
+	repository := &fakePollRepository{
+		fakeMessageRepository: newFakeMessageRepository(message),
+	}
+
+	for i, userID := range []string{testSenderID, testRecipientID} {
+		repository.votes = append(repository.votes, chatdomain.PollVote{
+			ID:        chatdomain.PollVoteID(message.ID, userID),
+			MessageID: message.ID,
+			UserID:    userID,
+			OptionIDs: []int{i},
+			CreatedAt: time.Now(),
+		})
+	}
+
+	return &Service{messageRepository: repository}, message
+}
+
+func TestListPollVotesDirect(t *testing.T) {
+	service, message := newTestPollService(false)
+
+	for _, userID := range []string{testSenderID, testRecipientID} {
+		votes, err := service.ListPollVotes(context.Background(), userID, message.ID)
+		require.NoError(t, err, userID)
+
+		assert.Len(t, votes, 2, userID)
+	}
+
+	_, err := service.ListPollVotes(context.Background(), testStrangerID, message.ID)
+	assert.True(t, domain.IsError(err, domain.ErrForbidden()), "unexpected error: %v", err)
+}
+
+func TestListPollVotesAnonymous(t *testing.T) {
+	service, message := newTestPollService(true)
+
+	// Voters of anonymous poll see only their own votes
+	votes, err := service.ListPollVotes(context.Background(), testRecipientID, message.ID)
+	require.NoError(t, err)
+	require.Len(t, votes, 1)
+
+	assert.Equal(t, testRecipientID, votes[0].UserID)
+	assert.Equal(t, []int{1}, votes[0].OptionIDs)
+}
+
+func TestListPollVotesDeleted(t *testing.T) {
+	message := newTestDirectMessage()
+	message.Deleted = true
+
+	service := &Service{messageRepository: newFakeMessageRepository(message)}
+
+	_, err := service.ListPollVotes(context.Background(), testRecipientID, message.ID)
+	assert.True(t, domain.IsError(err, chatdomain.ErrPollNotFound()), "unexpected error: %v", err)
+}
@@ -0,0 +1,224 @@
+package chatservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	"github.com/undefined7887/harmony-backend/internal/util"
+)
+
+const (
+	// Maximum time of writing remaining receipts on shutdown
+	receiptsShutdownTimeout = time.Second * 10
+)
+
+// delivery is an acknowledged delivery of message, waiting for writing
+type delivery struct {
+	message chatdomain.Message
+	receipt chatdomain.Receipt
+}
+
+type deliveryKey struct {
+	messageID string
+	userID    string
+}
+
+func NewReceiptWriterRunner(lifecycle fx.Lifecycle, logger *zap.Logger, service *Service) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		done        = make(chan struct{})
+	)
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			logger.Info("starting background receipts writer")
+
+			go func() {
+				defer close(done)
+
+				service.BackgroundWriteDeliveries(ctx, logger)
+			}()
+
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			logger.Info("stopping background receipts writer")
+
+			cancel()
+
+			select {
+			case <-done:
+				return nil
+
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+// AckDelivery acknowledges delivery of messages to user, receipts are written asynchronously
+func (s *Service) AckDelivery(ctx context.Context, userID string, messageIDs []string) error {
+	messageIDs = lo.Uniq(messageIDs)
+
+	messages, err := s.messageRepository.ListByIDs(ctx, messageIDs)
+	if err != nil {
+		return err
+	}
+
+	if len(messages) != len(messageIDs) {
+		return chatdomain.ErrMessageNotFound()
+	}
+
+	for _, message := range messages {
+		if err := s.checkMessageAccess(ctx, userID, message); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+
+	for _, message := range messages {
+		delivered := lo.ContainsBy(message.Deliveries, func(receipt chatdomain.Receipt) bool {
+			return receipt.UserID == userID
+		})
+
+		// Repeated acknowledgements are skipped
+		if message.UserID == userID || delivered {
+			continue
+		}
+
+		// Request is rejected instead of waiting for writer, client retries acknowledgement later.
+		// Already queued receipts are written, repeated ones are coalesced
+		select {
+		case s.deliveries <- delivery{
+			message: message,
+			receipt: chatdomain.Receipt{
+				UserID:    userID,
+				CreatedAt: now,
+			},
+		}:
+
+		default:
+			return chatdomain.ErrReceiptsBusy()
+		}
+	}
+
+	return nil
+}
+
+// BackgroundWriteDeliveries coalesces acknowledged deliveries into batched writes
+func (s *Service) BackgroundWriteDeliveries(ctx context.Context, logger *zap.Logger) {
+	ticker := time.NewTicker(s.config.ReceiptsFlushInterval)
+	defer ticker.Stop()
+
+	// Same delivery can be acknowledged several times before flushing
+	batch := make(map[deliveryKey]delivery)
+
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := s.writeDeliveries(ctx, lo.Values(batch)); err != nil {
+			logger.Warn("background write deliveries error", zap.Int("count", len(batch)), zap.Error(err))
+		}
+
+		batch = make(map[deliveryKey]delivery)
+	}
+
+	add := func(item delivery) {
+		batch[deliveryKey{
+			messageID: item.message.ID,
+			userID:    item.receipt.UserID,
+		}] = item
+	}
+
+	for {
+		select {
+		case item := <-s.deliveries:
+			add(item)
+
+			if len(batch) >= s.config.ReceiptsBatchSize {
+				flush(ctx)
+			}
+
+		case <-ticker.C:
+			flush(ctx)
+
+		case <-ctx.Done():
+			ctx, cancel := context.WithTimeout(context.Background(), receiptsShutdownTimeout)
+			defer cancel()
+
+			// Writing remaining receipts before shutdown
+			for {
+				select {
+				case item := <-s.deliveries:
+					add(item)
+
+				default:
+					flush(ctx)
+
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *Service) writeDeliveries(ctx context.Context, deliveries []delivery) error {
+	err := s.messageRepository.AddDeliveries(ctx, util.Map(deliveries, func(item delivery) chatdomain.MessageReceipt {
+		return chatdomain.MessageReceipt{
+			MessageID: item.message.ID,
+			Receipt:   item.receipt,
+		}
+	}))
+
+	if err != nil {
+		return err
+	}
+
+	// Only senders are notified about deliveries
+	for _, item := range deliveries {
+		s.centrifugoBroadcast(
+			ctx,
+			[]string{chatdomain.ChannelDeliveryUpdates(item.message.UserID)},
+			chatdomain.UpdateDeliveryNotification{
+				MessageID: item.message.ID,
+				PeerID:    item.message.PeerID,
+				PeerType:  item.message.PeerType,
+				UserID:    item.receipt.UserID,
+				DeliverAt: item.receipt.CreatedAt,
+			},
+		)
+	}
+
+	return nil
+}
+
+// ListMessageReceipts returns receipts of participants, who received and read message ("seen by")
+func (s *Service) ListMessageReceipts(
+	ctx context.Context,
+	userID, id string,
+) (deliveredTo, readBy []chatdomain.ReceiptDTO, err error) {
+	message, err := s.getMessage(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	readStates, err := s.chatRepository.ListReads(ctx, message.ChatID, message.CreatedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reads := message.Reads(readStates)
+
+	return util.Map(chatdomain.DeliveredReceipts(message, reads), chatdomain.MapReceiptDTO),
+		util.Map(reads, chatdomain.MapReceiptDTO),
+		nil
+}
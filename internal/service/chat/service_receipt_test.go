@@ -0,0 +1,163 @@
+package chatservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+)
+
+// fakeChatRepository records read states, not implemented methods panic
+type fakeChatRepository struct {
+	chatdomain.ChatRepository
+
+	reads []chatdomain.ReadState
+}
+
+func (f *fakeChatRepository) UpdateRead(_ context.Context, state chatdomain.ReadState) (bool, error) {
+	f.reads = append(f.reads, state)
+
+	return true, nil
+}
+
+func (f *fakeChatRepository) ListReads(_ context.Context, chatID string, sentAt time.Time) ([]chatdomain.ReadState, error) {
+	var result []chatdomain.ReadState
+
+	for _, state := range f.reads {
+		if state.ChatID == chatID && !state.MessageCreatedAt.Before(sentAt) {
+			result = append(result, state)
+		}
+	}
+
+	return result, nil
+}
+
+func (f *fakeChatRepository) ListReadSummaries(
+	_ context.Context,
+	userID string,
+	chatIDs []string,
+) ([]chatdomain.ReadSummary, error) {
+	summaries := make(map[string]chatdomain.ReadSummary)
+
+	for _, state := range f.reads {
+		if state.UserID == userID || !lo.Contains(chatIDs, state.ChatID) {
+			continue
+		}
+
+		if summary := summaries[state.ChatID]; summary.MessageCreatedAt.Before(state.MessageCreatedAt) {
+			summaries[state.ChatID] = chatdomain.ReadSummary{
+				ChatID:           state.ChatID,
+				MessageCreatedAt: state.MessageCreatedAt,
+			}
+		}
+	}
+
+	return lo.Values(summaries), nil
+}
+
+func TestAckDeliveryDirect(t *testing.T) {
+	message := newTestDirectMessage()
+
+	service := &Service{
+		messageRepository: newFakeMessageRepository(message),
+		deliveries:        make(chan delivery, 1),
+	}
+
+	// Only recipient acknowledges delivery, sender's acknowledgement is skipped
+	require.NoError(t, service.AckDelivery(context.Background(), testRecipientID, []string{message.ID}))
+	require.NoError(t, service.AckDelivery(context.Background(), testSenderID, []string{message.ID}))
+
+	require.Len(t, service.deliveries, 1)
+
+	item := <-service.deliveries
+	assert.Equal(t, message.ID, item.message.ID)
+	assert.Equal(t, testRecipientID, item.receipt.UserID)
+
+	err := service.AckDelivery(context.Background(), testStrangerID, []string{message.ID})
+	assert.True(t, domain.IsError(err, domain.ErrForbidden()), "unexpected error: %v", err)
+}
+
+func TestAckDeliveryBusy(t *testing.T) {
+	message := newTestDirectMessage()
+
+	service := &Service{
+		messageRepository: newFakeMessageRepository(message),
+		deliveries:        make(chan delivery),
+	}
+
+	// Writer doesn't read queue, so request must not wait for it
+	err := service.AckDelivery(context.Background(), testRecipientID, []string{message.ID})
+	assert.True(t, domain.IsError(err, chatdomain.ErrReceiptsBusy()), "unexpected error: %v", err)
+}
+
+func TestUpdateChatReadDirect(t *testing.T) {
+	message := newTestDirectMessage()
+	chatRepository := &fakeChatRepository{}
+
+	service := &Service{
+		messageRepository: newFakeMessageRepository(message),
+		chatRepository:    chatRepository,
+		centrifugoClient:  newTestCentrifugoClient(t),
+	}
+
+	require.NoError(t, service.UpdateChatRead(
+		context.Background(),
+		testRecipientID,
+		testSenderID,
+		chatdomain.PeerTypeUser,
+		message.ID,
+	))
+
+	require.Len(t, chatRepository.reads, 1)
+	assert.Equal(t, message.ChatID, chatRepository.reads[0].ChatID)
+	assert.Equal(t, testRecipientID, chatRepository.reads[0].UserID)
+	assert.Equal(t, message.ID, chatRepository.reads[0].MessageID)
+
+	err := service.UpdateChatRead(
+		context.Background(),
+		testStrangerID,
+		testSenderID,
+		chatdomain.PeerTypeUser,
+		message.ID,
+	)
+	assert.True(t, domain.IsError(err, domain.ErrForbidden()), "unexpected error: %v", err)
+}
+
+func TestGetMessageReads(t *testing.T) {
+	message := newTestDirectMessage()
+	readAt := time.Now()
+
+	service := &Service{
+		messageRepository: newFakeMessageRepository(message),
+		chatRepository: &fakeChatRepository{
+			reads: []chatdomain.ReadState{
+				{
+					ID:               chatdomain.ReadStateID(message.ChatID, testRecipientID),
+					ChatID:           message.ChatID,
+					UserID:           testRecipientID,
+					MessageID:        message.ID,
+					MessageCreatedAt: message.CreatedAt,
+					UpdatedAt:        readAt,
+				},
+			},
+		},
+	}
+
+	dto, err := service.GetMessage(context.Background(), testSenderID, message.ID)
+	require.NoError(t, err)
+
+	assert.True(t, dto.Read)
+	require.Len(t, dto.ReadBy, 1)
+	assert.Equal(t, testRecipientID, dto.ReadBy[0].UserID)
+	assert.Equal(t, readAt, dto.ReadBy[0].CreatedAt)
+
+	// Read messages are delivered too
+	require.Len(t, dto.DeliveredTo, 1)
+	assert.Equal(t, testRecipientID, dto.DeliveredTo[0].UserID)
+}
@@ -0,0 +1,270 @@
+package chatservice
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	filedomain "github.com/undefined7887/harmony-backend/internal/domain/file"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+	"github.com/undefined7887/harmony-backend/internal/util"
+)
+
+func NewScheduledDispatcherRunner(lifecycle fx.Lifecycle, logger *zap.Logger, service *Service) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		done        = make(chan struct{})
+	)
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			logger.Info("starting background scheduled messages dispatcher")
+
+			go func() {
+				defer close(done)
+
+				service.BackgroundDispatchScheduled(ctx, logger)
+			}()
+
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			logger.Info("stopping background scheduled messages dispatcher")
+
+			cancel()
+
+			select {
+			case <-done:
+				return nil
+
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+// ScheduleMessage checks message like CreateMessage does and saves it for sending at provided time
+func (s *Service) ScheduleMessage(
+	ctx context.Context,
+	userID, peerID, peerType, text string,
+	attachmentIDs []string,
+	poll *chatdomain.Poll,
+	replyToID, threadRootID string,
+	sendAt time.Time,
+) (chatdomain.ScheduledMessageDTO, error) {
+	now := time.Now()
+
+	if err := s.checkSendTime(sendAt, now); err != nil {
+		return chatdomain.ScheduledMessageDTO{}, err
+	}
+
+	// Poll must be open when it's sent
+	if err := s.checkPoll(poll, sendAt); err != nil {
+		return chatdomain.ScheduledMessageDTO{}, err
+	}
+
+	// Message is checked again before sending, because access may be lost
+	message, err := s.prepareMessage(ctx, userID, peerID, peerType, text, attachmentIDs, poll, replyToID, threadRootID)
+	if err != nil {
+		return chatdomain.ScheduledMessageDTO{}, err
+	}
+
+	scheduled := chatdomain.ScheduledMessage{
+		ID:            message.ID,
+		UserID:        userID,
+		PeerID:        peerID,
+		PeerType:      peerType,
+		ChatID:        message.ChatID,
+		Text:          text,
+		AttachmentIDs: attachmentIDs,
+		Poll:          poll,
+		ReplyToID:     replyToID,
+		ThreadRootID:  threadRootID,
+		SendAt:        sendAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	_, err = s.scheduledRepository.Create(ctx, &scheduled, s.config.ScheduledLimit)
+	if repository.IsLimitExceededErr(err) {
+		return chatdomain.ScheduledMessageDTO{}, chatdomain.ErrScheduledMessagesLimitExceeded()
+	}
+
+	// Attachments were deleted after they had been checked
+	if repository.IsFilesDeletedErr(err) {
+		return chatdomain.ScheduledMessageDTO{}, filedomain.ErrFileNotFound()
+	}
+
+	if err != nil {
+		return chatdomain.ScheduledMessageDTO{}, err
+	}
+
+	return chatdomain.MapScheduledMessageDTO(scheduled), nil
+}
+
+func (s *Service) ListScheduledMessages(ctx context.Context, userID, peerID, peerType string) ([]chatdomain.ScheduledMessageDTO, error) {
+	var chatID string
+
+	if peerID != "" {
+		chatID = chatdomain.ChatID(userID, peerID, peerType)
+	}
+
+	messages, err := s.scheduledRepository.List(ctx, userID, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Map(messages, chatdomain.MapScheduledMessageDTO), nil
+}
+
+func (s *Service) UpdateScheduledMessage(ctx context.Context, userID, id, text string, sendAt time.Time) error {
+	now := time.Now()
+
+	if err := s.checkSendTime(sendAt, now); err != nil {
+		return err
+	}
+
+	message, err := s.scheduledRepository.Get(ctx, id, userID)
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.ErrScheduledMessageNotFound()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Same rules as for message creation
+	if message.Poll != nil {
+		if text != "" {
+			return domain.ErrBadRequest(errors.New("text is not allowed for polls"))
+		}
+
+		if err := s.checkPoll(message.Poll, sendAt); err != nil {
+			return err
+		}
+	} else if text == "" && len(message.AttachmentIDs) == 0 {
+		return domain.ErrBadRequest(errors.New("text is required for messages without attachments"))
+	}
+
+	// Message, which is being sent, can't be modified
+	_, err = s.scheduledRepository.Update(ctx, id, userID, text, sendAt, now)
+	if repository.IsNoDocumentsErr(err) {
+		return chatdomain.ErrScheduledMessageNotFound()
+	}
+
+	return err
+}
+
+func (s *Service) DeleteScheduledMessage(ctx context.Context, userID, id string) error {
+	deleted, err := s.scheduledRepository.Delete(ctx, id, userID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return chatdomain.ErrScheduledMessageNotFound()
+	}
+
+	return nil
+}
+
+func (s *Service) checkSendTime(sendAt, now time.Time) error {
+	if !sendAt.After(now) || sendAt.After(now.Add(s.config.ScheduledMaxDelay)) {
+		return chatdomain.ErrSendTimeInvalid()
+	}
+
+	return nil
+}
+
+// BackgroundDispatchScheduled sends due scheduled messages until context is cancelled
+func (s *Service) BackgroundDispatchScheduled(ctx context.Context, logger *zap.Logger) {
+	ticker := time.NewTicker(s.config.ScheduledDispatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.dispatchScheduled(ctx, logger)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatchScheduled sends all due messages, which are not claimed by other instances
+func (s *Service) dispatchScheduled(ctx context.Context, logger *zap.Logger) {
+	for ctx.Err() == nil {
+		now := time.Now()
+
+		scheduled, err := s.scheduledRepository.Claim(ctx, now, now.Add(s.config.ScheduledLeaseTime))
+		if repository.IsNoDocumentsErr(err) {
+			return
+		}
+
+		if err != nil {
+			logger.Warn("claim scheduled message error", zap.Error(err))
+
+			return
+		}
+
+		messageLogger := logger.With(zap.String("id", scheduled.ID))
+
+		if err := s.sendScheduled(ctx, scheduled); err != nil {
+			var domainErr *domain.Error
+
+			// Message can't be sent anymore, for example user left group.
+			// Other errors are retried after lease is expired
+			if !errors.As(err, &domainErr) {
+				messageLogger.Warn("send scheduled message error", zap.Error(err))
+
+				continue
+			}
+
+			messageLogger.Info("scheduled message dropped", zap.Error(err))
+		}
+
+		if _, err := s.scheduledRepository.DeleteSent(ctx, scheduled.ID); err != nil {
+			messageLogger.Warn("delete scheduled message error", zap.Error(err))
+		}
+	}
+}
+
+func (s *Service) sendScheduled(ctx context.Context, scheduled chatdomain.ScheduledMessage) error {
+	// Message was sent before, but scheduled message wasn't deleted
+	_, err := s.messageRepository.Get(ctx, scheduled.ID)
+	if err == nil {
+		return nil
+	}
+
+	if !repository.IsNoDocumentsErr(err) {
+		return err
+	}
+
+	message, err := s.prepareMessage(
+		ctx,
+		scheduled.UserID,
+		scheduled.PeerID,
+		scheduled.PeerType,
+		scheduled.Text,
+		scheduled.AttachmentIDs,
+		scheduled.Poll,
+		scheduled.ReplyToID,
+		scheduled.ThreadRootID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	// Unique id guarantees, that message is sent only once
+	message.ID = scheduled.ID
+
+	return s.createMessage(ctx, &message)
+}
@@ -0,0 +1,186 @@
+package chatservice
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	userdomain "github.com/undefined7887/harmony-backend/internal/domain/user"
+	mongodatabase "github.com/undefined7887/harmony-backend/internal/infrastructure/database/mongo"
+	chatrepo "github.com/undefined7887/harmony-backend/internal/repository/chat"
+)
+
+// Tests require running mongo with replica set, for example from deploy/local.docker-compose.yml:
+//
+//	HARMONY_TEST_MONGO_ADDRESS=127.0.0.1:27017 go test -run=Dispatch ./internal/service/chat
+const testMongoAddressVariable = "HARMONY_TEST_MONGO_ADDRESS"
+
+// fakeUserRepository treats all users as existing, not implemented methods panic
+type fakeUserRepository struct {
+	userdomain.Repository
+}
+
+func (f *fakeUserRepository) Exists(context.Context, string) (bool, error) {
+	return true, nil
+}
+
+func newTestDatabase(t *testing.T) *mongo.Database {
+	address := os.Getenv(testMongoAddressVariable)
+	if address == "" {
+		t.Skipf("%s is not set", testMongoAddressVariable)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	database, err := mongodatabase.NewDatabase(&config.Mongo{
+		Address:  address,
+		Direct:   true,
+		Database: "harmony_test_" + domain.ID(),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, database.Client().Connect(ctx))
+
+	t.Cleanup(func() {
+		_ = database.Drop(context.Background())
+		_ = database.Client().Disconnect(context.Background())
+	})
+
+	return database
+}
+
+// newTestDispatcher returns service of single instance, which shares database with other instances
+func newTestDispatcher(t *testing.T, database *mongo.Database) *Service {
+	return &Service{
+		config: &config.Chat{
+			ScheduledLeaseTime: time.Minute,
+		},
+		userRepository:      &fakeUserRepository{},
+		messageRepository:   chatrepo.NewMongoMessageRepository(database),
+		chatRepository:      chatrepo.NewMongoChatRepository(database),
+		groupRepository:     chatrepo.NewMongoGroupRepository(database),
+		scheduledRepository: chatrepo.NewMongoScheduledMessageRepository(database),
+		centrifugoClient:    newTestCentrifugoClient(t),
+	}
+}
+
+func TestDispatchScheduledOnce(t *testing.T) {
+	tests := []struct {
+		name string
+
+		// Previous instance claimed message and stopped before lease was expired
+		prepare func(t *testing.T, service *Service)
+	}{
+		{
+			name:    "not claimed",
+			prepare: func(*testing.T, *Service) {},
+		},
+		{
+			name: "lease expired before sending",
+			prepare: func(t *testing.T, service *Service) {
+				_, err := service.scheduledRepository.Claim(context.Background(), time.Now(), time.Now().Add(-time.Second))
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "lease expired after sending",
+			prepare: func(t *testing.T, service *Service) {
+				scheduled, err := service.scheduledRepository.Claim(context.Background(), time.Now(), time.Now().Add(-time.Second))
+				require.NoError(t, err)
+
+				require.NoError(t, service.sendScheduled(context.Background(), scheduled))
+			},
+		},
+		{
+			name: "lease expired after sending twice",
+			prepare: func(t *testing.T, service *Service) {
+				scheduled, err := service.scheduledRepository.Claim(context.Background(), time.Now(), time.Now().Add(-time.Second))
+				require.NoError(t, err)
+
+				// Both sends could miss existing message, so one of them is deduplicated by repository
+				var wg sync.WaitGroup
+
+				for i := 0; i < 2; i++ {
+					wg.Add(1)
+
+					go func() {
+						defer wg.Done()
+
+						assert.NoError(t, service.sendScheduled(context.Background(), scheduled))
+					}()
+				}
+
+				wg.Wait()
+			},
+		},
+	}
+
+	database := newTestDatabase(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			dispatchers := []*Service{newTestDispatcher(t, database), newTestDispatcher(t, database)}
+
+			scheduled := chatdomain.ScheduledMessage{
+				ID:        domain.ID(),
+				UserID:    domain.ID(),
+				PeerID:    domain.ID(),
+				PeerType:  chatdomain.PeerTypeUser,
+				Text:      "scheduled",
+				SendAt:    time.Now().Add(-time.Second),
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			}
+
+			_, err := dispatchers[0].scheduledRepository.Create(ctx, &scheduled, 1)
+			require.NoError(t, err)
+
+			test.prepare(t, dispatchers[0])
+
+			var wg sync.WaitGroup
+
+			for _, dispatcher := range dispatchers {
+				wg.Add(1)
+
+				go func(dispatcher *Service) {
+					defer wg.Done()
+
+					dispatcher.dispatchScheduled(ctx, zap.NewNop())
+				}(dispatcher)
+			}
+
+			wg.Wait()
+
+			chatID := chatdomain.ChatID(scheduled.UserID, scheduled.PeerID, scheduled.PeerType)
+
+			messages, err := dispatchers[0].messageRepository.List(ctx, chatID, scheduled.UserID, domain.Pagination{
+				Limit: domain.PaginationDefaultLimit,
+			})
+			require.NoError(t, err)
+			require.Len(t, messages.Items, 1)
+
+			assert.Equal(t, scheduled.ID, messages.Items[0].ID)
+
+			// Message with scheduled id is sent only once
+			inserted, err := dispatchers[0].messageRepository.Create(ctx, &messages.Items[0])
+			require.NoError(t, err)
+			assert.False(t, inserted)
+
+			pending, err := dispatchers[0].scheduledRepository.List(ctx, scheduled.UserID, "")
+			require.NoError(t, err)
+			assert.Empty(t, pending)
+		})
+	}
+}
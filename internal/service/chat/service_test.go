@@ -0,0 +1,236 @@
+package chatservice
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	chatdomain "github.com/undefined7887/harmony-backend/internal/domain/chat"
+	filedomain "github.com/undefined7887/harmony-backend/internal/domain/file"
+	"github.com/undefined7887/harmony-backend/internal/infrastructure/storage"
+	fileservice "github.com/undefined7887/harmony-backend/internal/service/file"
+	"github.com/undefined7887/harmony-backend/internal/third_party/centrifugo"
+)
+
+// Chat ids are combined from user ids, so they must be valid ids
+var (
+	testSenderID    = domain.ID()
+	testRecipientID = domain.ID()
+	testStrangerID  = domain.ID()
+)
+
+// fakeMessageRepository keeps messages in memory, not implemented methods panic
+type fakeMessageRepository struct {
+	chatdomain.MessageRepository
+
+	messages map[string]chatdomain.Message
+}
+
+func newFakeMessageRepository(messages ...chatdomain.Message) *fakeMessageRepository {
+	repository := &fakeMessageRepository{
+		messages: make(map[string]chatdomain.Message),
+	}
+
+	for _, message := range messages {
+		repository.messages[message.ID] = message
+	}
+
+	return repository
+}
+
+func (f *fakeMessageRepository) Get(_ context.Context, id string) (chatdomain.Message, error) {
+	message, ok := f.messages[id]
+	if !ok {
+		return chatdomain.Message{}, mongo.ErrNoDocuments
+	}
+
+	return message, nil
+}
+
+func (f *fakeMessageRepository) ListByIDs(_ context.Context, ids []string) ([]chatdomain.Message, error) {
+	var result []chatdomain.Message
+
+	for _, id := range ids {
+		if message, ok := f.messages[id]; ok {
+			result = append(result, message)
+		}
+	}
+
+	return result, nil
+}
+
+// newTestCentrifugoClient returns client of fake centrifugo, which accepts all commands
+func newTestCentrifugoClient(t *testing.T) *centrifugo.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		_, _ = w.Write([]byte(`{"result":{}}`))
+	}))
+
+	t.Cleanup(server.Close)
+
+	return centrifugo.NewClient(&config.Centrifugo{
+		ApiAddress: server.URL,
+	})
+}
+
+// newTestDirectMessage returns message, sent by sender to recipient in user chat
+func newTestDirectMessage() chatdomain.Message {
+	message := newMessage(testSenderID, testRecipientID, chatdomain.PeerTypeUser, "hello")
+	message.CreatedAt = time.Now().Add(-time.Minute)
+
+	return message
+}
+
+func TestCheckMessageAccessDirect(t *testing.T) {
+	message := newTestDirectMessage()
+
+	tests := []struct {
+		name   string
+		userID string
+		err    *domain.Error
+	}{
+		{"sender", testSenderID, nil},
+		{"recipient", testRecipientID, nil},
+		{"non-participant", testStrangerID, domain.ErrForbidden()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := (&Service{}).checkMessageAccess(context.Background(), test.userID, message)
+
+			if test.err == nil {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.True(t, domain.IsError(err, test.err), "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestGetMessageDirect(t *testing.T) {
+	message := newTestDirectMessage()
+
+	service := &Service{
+		messageRepository: newFakeMessageRepository(message),
+	}
+
+	for _, userID := range []string{testSenderID, testRecipientID} {
+		result, err := service.getMessage(context.Background(), userID, message.ID)
+		require.NoError(t, err, userID)
+
+		assert.Equal(t, message.ID, result.ID, userID)
+	}
+
+	_, err := service.getMessage(context.Background(), testStrangerID, message.ID)
+	assert.True(t, domain.IsError(err, domain.ErrForbidden()), "unexpected error: %v", err)
+}
+
+// fakeFileRepository keeps files in memory, not implemented methods panic
+type fakeFileRepository struct {
+	filedomain.Repository
+
+	files map[string]filedomain.File
+}
+
+func (f *fakeFileRepository) Get(_ context.Context, id string) (filedomain.File, error) {
+	file, ok := f.files[id]
+	if !ok {
+		return filedomain.File{}, mongo.ErrNoDocuments
+	}
+
+	return file, nil
+}
+
+func (f *fakeFileRepository) ListByIDs(_ context.Context, ids []string) ([]filedomain.File, error) {
+	var result []filedomain.File
+
+	for _, id := range ids {
+		if file, ok := f.files[id]; ok {
+			result = append(result, file)
+		}
+	}
+
+	return result, nil
+}
+
+func (f *fakeFileRepository) Delete(_ context.Context, ids []string) (int64, error) {
+	var count int64
+
+	for _, id := range ids {
+		if _, ok := f.files[id]; ok {
+			delete(f.files, id)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// newTestFileService returns file service with local storage, which contains provided files
+func newTestFileService(t *testing.T, files ...filedomain.File) (*fileservice.Service, *fakeFileRepository) {
+	localStorage, err := storage.NewLocalStorage(&config.Storage{
+		Type:      storage.TypeLocal,
+		LocalPath: filepath.Join(t.TempDir(), "files"),
+	})
+	require.NoError(t, err)
+
+	fileRepository := &fakeFileRepository{
+		files: make(map[string]filedomain.File),
+	}
+
+	for _, file := range files {
+		fileRepository.files[file.ID] = file
+	}
+
+	return fileservice.NewService(&config.File{
+		UrlLifetime: time.Minute,
+		UrlSecret:   "test-secret",
+	}, zap.NewNop(), fileRepository, localStorage), fileRepository
+}
+
+func TestGetMessageAttachmentDirect(t *testing.T) {
+	file := filedomain.File{
+		ID:       domain.ID(),
+		UserID:   testSenderID,
+		Name:     "photo.png",
+		MimeType: "image/png",
+	}
+
+	message := newTestDirectMessage()
+	message.Attachments = []chatdomain.Attachment{chatdomain.NewAttachment(file)}
+
+	fileService, _ := newTestFileService(t, file)
+
+	service := &Service{
+		messageRepository: newFakeMessageRepository(message),
+		fileService:       fileService,
+	}
+
+	for _, userID := range []string{testSenderID, testRecipientID} {
+		dto, err := service.GetMessageAttachment(context.Background(), userID, message.ID, file.ID)
+		require.NoError(t, err, userID)
+
+		assert.Equal(t, file.ID, dto.ID, userID)
+		assert.NotEmpty(t, dto.URL, userID)
+	}
+
+	_, err := service.GetMessageAttachment(context.Background(), testStrangerID, message.ID, file.ID)
+	assert.True(t, domain.IsError(err, domain.ErrForbidden()), "unexpected error: %v", err)
+
+	// Files of user are not available through other messages
+	_, err = service.GetMessageAttachment(context.Background(), testRecipientID, message.ID, domain.ID())
+	assert.True(t, domain.IsError(err, filedomain.ErrFileNotFound()), "unexpected error: %v", err)
+}
@@ -0,0 +1,8 @@
+package fileservice
+
+import "go.uber.org/fx"
+
+var Module = fx.Options(
+	fx.Provide(NewService),
+	fx.Invoke(NewServiceRunner),
+)
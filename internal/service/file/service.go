@@ -0,0 +1,371 @@
+package fileservice
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	filedomain "github.com/undefined7887/harmony-backend/internal/domain/file"
+	zaplog "github.com/undefined7887/harmony-backend/internal/infrastructure/log/zap"
+	"github.com/undefined7887/harmony-backend/internal/infrastructure/storage"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+)
+
+const (
+	// Path of download endpoint, used if storage doesn't support direct downloading
+	downloadPath = "/api/v1/file/%s/download"
+
+	// Number of bytes used for content type detection
+	sniffSize = 512
+
+	// Used if client didn't provide file name
+	defaultName = "file"
+)
+
+type Service struct {
+	config *config.File
+	logger *zap.Logger
+
+	fileRepository filedomain.Repository
+	storage        storage.Storage
+
+	// Processing state, see service_processing.go
+	queue             chan string
+	processedHandlers []ProcessedHandler
+}
+
+func NewService(
+	config *config.File,
+	logger *zap.Logger,
+	fileRepository filedomain.Repository,
+	storage storage.Storage,
+) *Service {
+	return &Service{
+		config:         config,
+		logger:         logger,
+		fileRepository: fileRepository,
+		storage:        storage,
+		queue:          make(chan string, config.ProcessingQueueSize),
+	}
+}
+
+func (s *Service) UploadFile(ctx context.Context, userID string, header *multipart.FileHeader) (filedomain.FileDTO, error) {
+	if header.Size > s.config.MaxSize {
+		return filedomain.FileDTO{}, filedomain.ErrFileTooLarge()
+	}
+
+	reader, err := header.Open()
+	if err != nil {
+		return filedomain.FileDTO{}, err
+	}
+
+	defer reader.Close()
+
+	// Content type from request can't be trusted
+	head := make([]byte, sniffSize)
+
+	n, err := io.ReadFull(reader, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return filedomain.FileDTO{}, err
+	}
+
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if err != nil {
+		return filedomain.FileDTO{}, err
+	}
+
+	if !s.isTypeAllowed(mimeType) {
+		return filedomain.FileDTO{}, filedomain.ErrFileTypeNotAllowed()
+	}
+
+	file := filedomain.File{
+		ID:        domain.ID(),
+		UserID:    userID,
+		Name:      fileName(header.Filename),
+		MimeType:  mimeType,
+		Size:      header.Size,
+		CreatedAt: time.Now(),
+
+		// Thumbnails and metadata are generated in background
+		Processing: filedomain.IsImage(mimeType),
+	}
+
+	hash := sha256.New()
+
+	if err := s.storage.Put(
+		ctx,
+		file.ID,
+		io.TeeReader(io.MultiReader(bytes.NewReader(head[:n]), reader), hash),
+		file.Size,
+		file.MimeType,
+	); err != nil {
+		return filedomain.FileDTO{}, err
+	}
+
+	file.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	if _, err := s.fileRepository.Create(ctx, &file); err != nil {
+		s.deleteBlob(ctx, file.ID)
+
+		return filedomain.FileDTO{}, err
+	}
+
+	if file.Processing {
+		s.enqueue(file.ID)
+	}
+
+	return filedomain.MapFileDTO(file), nil
+}
+
+// GetFile returns file with download url, only owner has access
+func (s *Service) GetFile(ctx context.Context, userID, id string) (filedomain.FileDTO, error) {
+	file, err := s.getFile(ctx, id)
+	if err != nil {
+		return filedomain.FileDTO{}, err
+	}
+
+	// Other users get access to file through messages
+	if file.UserID != userID {
+		return filedomain.FileDTO{}, filedomain.ErrFileNotFound()
+	}
+
+	return s.mapFileDTOWithURL(ctx, file)
+}
+
+// GetFileURL returns file with download url, access must be checked by caller
+func (s *Service) GetFileURL(ctx context.Context, id string) (filedomain.FileDTO, error) {
+	file, err := s.getFile(ctx, id)
+	if err != nil {
+		return filedomain.FileDTO{}, err
+	}
+
+	return s.mapFileDTOWithURL(ctx, file)
+}
+
+// GetUserFiles returns files with provided ids, all of them must be owned by user
+func (s *Service) GetUserFiles(ctx context.Context, userID string, ids []string) ([]filedomain.File, error) {
+	ids = lo.Uniq(ids)
+
+	files, err := s.fileRepository.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	filesByID := lo.KeyBy(files, func(file filedomain.File) string {
+		return file.ID
+	})
+
+	result := make([]filedomain.File, 0, len(ids))
+
+	// Keeping order of provided ids
+	for _, id := range ids {
+		file, ok := filesByID[id]
+		if !ok || file.UserID != userID {
+			return nil, filedomain.ErrFileNotFound()
+		}
+
+		result = append(result, file)
+	}
+
+	return result, nil
+}
+
+// DownloadFile checks url signature and returns content of file or its thumbnail.
+// For thumbnails returned file describes thumbnail content
+func (s *Service) DownloadFile(
+	ctx context.Context,
+	id string,
+	thumbnail int,
+	expires int64,
+	signature string,
+) (filedomain.File, io.ReadCloser, error) {
+	key := id
+	if thumbnail > 0 {
+		key = filedomain.ThumbnailKey(id, thumbnail)
+	}
+
+	if time.Now().Unix() > expires || !hmac.Equal([]byte(signature), []byte(s.signature(key, expires))) {
+		return filedomain.File{}, nil, filedomain.ErrFileUrlInvalid()
+	}
+
+	file, err := s.getFile(ctx, id)
+	if err != nil {
+		return filedomain.File{}, nil, err
+	}
+
+	if thumbnail > 0 {
+		item, ok := lo.Find(file.Thumbnails, func(item filedomain.Thumbnail) bool {
+			return item.Size == thumbnail
+		})
+
+		if !ok {
+			return filedomain.File{}, nil, filedomain.ErrFileNotFound()
+		}
+
+		file.MimeType = item.MimeType
+		file.Size = item.FileSize
+	}
+
+	reader, err := s.storage.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return filedomain.File{}, nil, filedomain.ErrFileNotFound()
+	}
+
+	if err != nil {
+		return filedomain.File{}, nil, err
+	}
+
+	return file, reader, nil
+}
+
+// DeleteFiles deletes files with their thumbnails, caller must ensure that files are not used anymore
+func (s *Service) DeleteFiles(ctx context.Context, ids []string) error {
+	files, err := s.fileRepository.ListByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	// Blobs are deleted first, so deleting can be retried on failures
+	for _, file := range files {
+		if err := s.storage.Delete(ctx, file.ID); err != nil {
+			return err
+		}
+
+		for _, thumbnail := range file.Thumbnails {
+			if err := s.storage.Delete(ctx, filedomain.ThumbnailKey(file.ID, thumbnail.Size)); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = s.fileRepository.Delete(ctx, ids)
+
+	return err
+}
+
+func (s *Service) getFile(ctx context.Context, id string) (filedomain.File, error) {
+	file, err := s.fileRepository.Get(ctx, id)
+	if repository.IsNoDocumentsErr(err) {
+		return filedomain.File{}, filedomain.ErrFileNotFound()
+	}
+
+	if err != nil {
+		return filedomain.File{}, err
+	}
+
+	return file, nil
+}
+
+func (s *Service) mapFileDTOWithURL(ctx context.Context, file filedomain.File) (filedomain.FileDTO, error) {
+	expireAt := time.Now().Add(s.config.UrlLifetime)
+
+	downloadURL, err := s.downloadURL(ctx, file.ID, 0, file.Name, expireAt)
+	if err != nil {
+		return filedomain.FileDTO{}, err
+	}
+
+	dto := filedomain.MapFileDTO(file)
+	dto.URL = downloadURL
+	dto.ExpireAt = &expireAt
+
+	for i := range dto.Thumbnails {
+		dto.Thumbnails[i].URL, err = s.downloadURL(ctx, file.ID, dto.Thumbnails[i].Size, file.Name, expireAt)
+		if err != nil {
+			return filedomain.FileDTO{}, err
+		}
+	}
+
+	return dto, nil
+}
+
+func (s *Service) downloadURL(
+	ctx context.Context,
+	id string,
+	thumbnail int,
+	name string,
+	expireAt time.Time,
+) (string, error) {
+	key := id
+	if thumbnail > 0 {
+		key = filedomain.ThumbnailKey(id, thumbnail)
+	}
+
+	downloadURL, err := s.storage.PresignedURL(ctx, key, name, s.config.UrlLifetime)
+	if err != nil {
+		return "", err
+	}
+
+	if downloadURL != "" {
+		return downloadURL, nil
+	}
+
+	// Storage doesn't support direct downloading, so file is downloaded through application
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expireAt.Unix(), 10))
+	query.Set("signature", s.signature(key, expireAt.Unix()))
+
+	if thumbnail > 0 {
+		query.Set("thumbnail", strconv.Itoa(thumbnail))
+	}
+
+	return fmt.Sprintf(downloadPath, id) + "?" + query.Encode(), nil
+}
+
+func (s *Service) signature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(s.config.UrlSecret))
+	mac.Write([]byte(fmt.Sprintf("%s:%d", key, expires)))
+
+	return domain.Base64.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Service) isTypeAllowed(mimeType string) bool {
+	return lo.ContainsBy(s.config.AllowedTypes, func(pattern string) bool {
+		matched, err := path.Match(pattern, mimeType)
+
+		return err == nil && matched
+	})
+}
+
+func (s *Service) deleteBlob(ctx context.Context, key string) {
+	if err := s.storage.Delete(ctx, key); err != nil {
+		zaplog.
+			UnpackLogger(ctx).
+			Warn("failed to delete blob", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func fileName(name string) string {
+	// Some clients send full path, including windows ones
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+
+	if name == "." || name == "/" {
+		return defaultName
+	}
+
+	runes := []rune(name)
+
+	// Keeping extension
+	if len(runes) > filedomain.NameSize {
+		runes = runes[len(runes)-filedomain.NameSize:]
+	}
+
+	return string(runes)
+}
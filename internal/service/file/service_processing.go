@@ -0,0 +1,283 @@
+package fileservice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"golang.org/x/image/draw"
+
+	// Registering gif decoder
+	_ "image/gif"
+
+	filedomain "github.com/undefined7887/harmony-backend/internal/domain/file"
+	zaplog "github.com/undefined7887/harmony-backend/internal/infrastructure/log/zap"
+	"github.com/undefined7887/harmony-backend/internal/repository"
+)
+
+const (
+	// Maximum time of processing for a single file
+	processingTimeout = time.Minute
+
+	// Claimed file is not processed by other instances until lease is expired,
+	// lease is longer than timeout, so results can be saved after processing
+	processingLeaseTime = processingTimeout * 2
+
+	// Images with more pixels are not decoded to protect from decompression bombs
+	processingMaxPixels = 50_000_000
+
+	thumbnailJpegQuality = 85
+)
+
+var errImageTooLarge = errors.New("image is too large")
+
+// ProcessedHandler is called after file processing is finished
+type ProcessedHandler func(ctx context.Context, file filedomain.File)
+
+// OnFileProcessed registers handler, must be called before application start
+func (s *Service) OnFileProcessed(handler ProcessedHandler) {
+	s.processedHandlers = append(s.processedHandlers, handler)
+}
+
+func NewServiceRunner(lifecycle fx.Lifecycle, logger *zap.Logger, service *Service) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		wg          sync.WaitGroup
+	)
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			logger.Info("starting file processing workers", zap.Int("count", service.config.ProcessingWorkers))
+
+			for i := 0; i < service.config.ProcessingWorkers; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					service.BackgroundProcessFiles(ctx)
+				}()
+			}
+
+			// Files, which were not processed before previous shutdown
+			go service.enqueueUnprocessed(ctx)
+
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			logger.Info("stopping file processing workers")
+
+			cancel()
+
+			done := make(chan struct{})
+
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				return nil
+
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+func (s *Service) BackgroundProcessFiles(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case id := <-s.queue:
+			s.processFile(ctx, id)
+		}
+	}
+}
+
+// enqueue doesn't wait for free space in queue, so uploading is not blocked by processing
+func (s *Service) enqueue(id string) {
+	select {
+	case s.queue <- id:
+
+	// File stays marked as processing and will be processed after restart
+	default:
+		s.logger.Warn("processing queue is full, file is not enqueued", zap.String("id", id))
+	}
+}
+
+func (s *Service) enqueueUnprocessed(ctx context.Context) {
+	files, err := s.fileRepository.ListProcessing(ctx)
+	if err != nil {
+		s.logger.Warn("failed to list unprocessed files", zap.Error(err))
+
+		return
+	}
+
+	for _, file := range files {
+		select {
+		case s.queue <- file.ID:
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Service) processFile(ctx context.Context, id string) {
+	ctx, cancel := context.WithTimeout(ctx, processingTimeout)
+	defer cancel()
+
+	// Used by handlers and storage
+	ctx = zaplog.PackLogger(ctx, s.logger.With(zap.String("file_id", id)))
+
+	// All instances enqueue unprocessed files after start, so file is processed only by instance, which claimed it
+	now := time.Now()
+
+	file, err := s.fileRepository.Claim(ctx, id, now, now.Add(processingLeaseTime))
+	if repository.IsNoDocumentsErr(err) {
+		return
+	}
+
+	if err != nil {
+		s.logger.Warn("failed to claim file for processing", zap.String("id", id), zap.Error(err))
+
+		return
+	}
+
+	width, height, thumbnails, err := s.processImage(ctx, file)
+	if err != nil {
+		s.logger.Warn("failed to process file", zap.String("id", id), zap.Error(err))
+	}
+
+	// Processing is finished even on errors, so broken files are not processed again
+	file, err = s.fileRepository.UpdateMedia(ctx, id, width, height, thumbnails)
+	if err != nil {
+		s.logger.Warn("failed to save processed file", zap.String("id", id), zap.Error(err))
+
+		return
+	}
+
+	for _, handler := range s.processedHandlers {
+		handler(ctx, file)
+	}
+}
+
+func (s *Service) processImage(ctx context.Context, file filedomain.File) (int, int, []filedomain.Thumbnail, error) {
+	reader, err := s.storage.Get(ctx, file.ID)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, s.config.MaxSize))
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	if imageConfig.Width*imageConfig.Height > processingMaxPixels {
+		return imageConfig.Width, imageConfig.Height, nil, errImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return imageConfig.Width, imageConfig.Height, nil, err
+	}
+
+	var thumbnails []filedomain.Thumbnail
+
+	for _, size := range s.config.ThumbnailSizes {
+		// Image is small enough already
+		if imageConfig.Width <= size && imageConfig.Height <= size {
+			continue
+		}
+
+		thumbnail, err := s.createThumbnail(ctx, file, img, size)
+		if err != nil {
+			return imageConfig.Width, imageConfig.Height, thumbnails, err
+		}
+
+		thumbnails = append(thumbnails, thumbnail)
+	}
+
+	return imageConfig.Width, imageConfig.Height, thumbnails, nil
+}
+
+func (s *Service) createThumbnail(
+	ctx context.Context,
+	file filedomain.File,
+	img image.Image,
+	size int,
+) (filedomain.Thumbnail, error) {
+	width, height := thumbnailBounds(img.Bounds().Dx(), img.Bounds().Dy(), size)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
+
+	var (
+		buffer   bytes.Buffer
+		mimeType string
+		err      error
+	)
+
+	// Keeping transparency of png and gif images
+	if file.MimeType == "image/jpeg" {
+		mimeType = "image/jpeg"
+		err = jpeg.Encode(&buffer, dst, &jpeg.Options{Quality: thumbnailJpegQuality})
+	} else {
+		mimeType = "image/png"
+		err = png.Encode(&buffer, dst)
+	}
+
+	if err != nil {
+		return filedomain.Thumbnail{}, err
+	}
+
+	thumbnail := filedomain.Thumbnail{
+		Size:     size,
+		Width:    width,
+		Height:   height,
+		MimeType: mimeType,
+		FileSize: int64(buffer.Len()),
+	}
+
+	if err := s.storage.Put(
+		ctx,
+		filedomain.ThumbnailKey(file.ID, size),
+		&buffer,
+		thumbnail.FileSize,
+		thumbnail.MimeType,
+	); err != nil {
+		return filedomain.Thumbnail{}, err
+	}
+
+	return thumbnail, nil
+}
+
+// thumbnailBounds fits image into square with provided side, keeping aspect ratio
+func thumbnailBounds(width, height, size int) (int, int) {
+	if width >= height {
+		return size, lo.Max([]int{1, height * size / width})
+	}
+
+	return lo.Max([]int{1, width * size / height}), size
+}
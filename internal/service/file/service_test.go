@@ -0,0 +1,310 @@
+package fileservice
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	filedomain "github.com/undefined7887/harmony-backend/internal/domain/file"
+	"github.com/undefined7887/harmony-backend/internal/infrastructure/storage"
+)
+
+type fakeFileRepository struct {
+	filedomain.Repository
+
+	files map[string]filedomain.File
+}
+
+func (f *fakeFileRepository) Create(_ context.Context, file *filedomain.File) (bool, error) {
+	f.files[file.ID] = *file
+
+	return true, nil
+}
+
+func (f *fakeFileRepository) Get(_ context.Context, id string) (filedomain.File, error) {
+	file, ok := f.files[id]
+	if !ok {
+		return filedomain.File{}, mongo.ErrNoDocuments
+	}
+
+	return file, nil
+}
+
+func (f *fakeFileRepository) Claim(_ context.Context, id string, now, lockedUntil time.Time) (filedomain.File, error) {
+	file, ok := f.files[id]
+	if !ok || !file.Processing || (file.LockedUntil != nil && file.LockedUntil.After(now)) {
+		return filedomain.File{}, mongo.ErrNoDocuments
+	}
+
+	file.LockedUntil = &lockedUntil
+	f.files[id] = file
+
+	return file, nil
+}
+
+func (f *fakeFileRepository) UpdateMedia(
+	_ context.Context,
+	id string,
+	width, height int,
+	thumbnails []filedomain.Thumbnail,
+) (filedomain.File, error) {
+	file := f.files[id]
+	file.Width, file.Height, file.Thumbnails = width, height, thumbnails
+	file.Processing, file.LockedUntil = false, nil
+
+	f.files[id] = file
+
+	return file, nil
+}
+
+func newTestService(t *testing.T) (*Service, *fakeFileRepository) {
+	localStorage, err := storage.NewLocalStorage(&config.Storage{
+		Type:      storage.TypeLocal,
+		LocalPath: filepath.Join(t.TempDir(), "files"),
+	})
+	require.NoError(t, err)
+
+	fileRepository := &fakeFileRepository{files: map[string]filedomain.File{}}
+
+	service := NewService(&config.File{
+		MaxSize:             1024,
+		AllowedTypes:        []string{"image/*"},
+		UrlLifetime:         time.Minute,
+		UrlSecret:           "test-secret",
+		ProcessingQueueSize: 1,
+	}, zap.NewNop(), fileRepository, localStorage)
+
+	return service, fileRepository
+}
+
+// newTestFile saves file with provided content directly to repository and storage
+func newTestFile(t *testing.T, service *Service, fileRepository *fakeFileRepository, content []byte) filedomain.File {
+	file := filedomain.File{
+		ID:       domain.ID(),
+		UserID:   domain.ID(),
+		Name:     "file.txt",
+		MimeType: "text/plain",
+		Size:     int64(len(content)),
+		Thumbnails: []filedomain.Thumbnail{
+			{Size: 64, MimeType: "image/png", FileSize: int64(len(content))},
+		},
+	}
+
+	ctx := context.Background()
+
+	require.NoError(t, service.storage.Put(ctx, file.ID, bytes.NewReader(content), file.Size, file.MimeType))
+	require.NoError(t, service.storage.Put(ctx, filedomain.ThumbnailKey(file.ID, 64), bytes.NewReader(content), file.Size, ""))
+
+	_, err := fileRepository.Create(ctx, &file)
+	require.NoError(t, err)
+
+	return file
+}
+
+// parseDownloadURL returns expires and signature from url, generated by service
+func parseDownloadURL(t *testing.T, downloadURL string) (int64, string) {
+	parsed, err := url.Parse(downloadURL)
+	require.NoError(t, err)
+
+	expires, err := strconv.ParseInt(parsed.Query().Get("expires"), 10, 64)
+	require.NoError(t, err)
+
+	return expires, parsed.Query().Get("signature")
+}
+
+func TestDownloadFileSignature(t *testing.T) {
+	service, fileRepository := newTestService(t)
+
+	content := []byte("content")
+
+	file := newTestFile(t, service, fileRepository, content)
+	otherFile := newTestFile(t, service, fileRepository, content)
+
+	dto, err := service.GetFileURL(context.Background(), file.ID)
+	require.NoError(t, err)
+
+	expires, signature := parseDownloadURL(t, dto.URL)
+	thumbnailExpires, thumbnailSignature := parseDownloadURL(t, dto.Thumbnails[0].URL)
+
+	expired := time.Now().Add(-time.Second).Unix()
+
+	tests := []struct {
+		name      string
+		id        string
+		thumbnail int
+		expires   int64
+		signature string
+		err       error
+	}{
+		{name: "valid", id: file.ID, expires: expires, signature: signature},
+		{name: "valid thumbnail", id: file.ID, thumbnail: 64, expires: thumbnailExpires, signature: thumbnailSignature},
+		{name: "expired", id: file.ID, expires: expired, signature: service.signature(file.ID, expired), err: filedomain.ErrFileUrlInvalid()},
+		{name: "extended expiry", id: file.ID, expires: expires + 3600, signature: signature, err: filedomain.ErrFileUrlInvalid()},
+		{name: "tampered signature", id: file.ID, expires: expires, signature: strings.ToUpper(signature), err: filedomain.ErrFileUrlInvalid()},
+		{name: "empty signature", id: file.ID, expires: expires, err: filedomain.ErrFileUrlInvalid()},
+		{name: "wrong file", id: otherFile.ID, expires: expires, signature: signature, err: filedomain.ErrFileUrlInvalid()},
+		{name: "thumbnail with file signature", id: file.ID, thumbnail: 64, expires: expires, signature: signature, err: filedomain.ErrFileUrlInvalid()},
+		{name: "file with thumbnail signature", id: file.ID, expires: thumbnailExpires, signature: thumbnailSignature, err: filedomain.ErrFileUrlInvalid()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, reader, err := service.DownloadFile(context.Background(), test.id, test.thumbnail, test.expires, test.signature)
+			if test.err != nil {
+				require.Equal(t, test.err, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			data, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			require.NoError(t, reader.Close())
+			require.Equal(t, content, data)
+		})
+	}
+}
+
+func TestDownloadFileOtherSecret(t *testing.T) {
+	service, fileRepository := newTestService(t)
+	file := newTestFile(t, service, fileRepository, []byte("content"))
+
+	otherService, _ := newTestService(t)
+	otherService.config.UrlSecret = "other-secret"
+
+	expires := time.Now().Add(time.Minute).Unix()
+
+	_, _, err := service.DownloadFile(context.Background(), file.ID, 0, expires, otherService.signature(file.ID, expires))
+	require.Equal(t, filedomain.ErrFileUrlInvalid(), err)
+}
+
+func newTestFileHeader(t *testing.T, name string, content []byte) *multipart.FileHeader {
+	var body bytes.Buffer
+
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", name)
+	require.NoError(t, err)
+
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = form.RemoveAll()
+	})
+
+	return form.File["file"][0]
+}
+
+func newTestPNG(t *testing.T) []byte {
+	var buffer bytes.Buffer
+
+	require.NoError(t, png.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, 1, 1))))
+
+	return buffer.Bytes()
+}
+
+func TestUploadFile(t *testing.T) {
+	service, fileRepository := newTestService(t)
+
+	dto, err := service.UploadFile(context.Background(), domain.ID(), newTestFileHeader(t, "C:\\photos\\pixel.png", newTestPNG(t)))
+	require.NoError(t, err)
+	require.Equal(t, "pixel.png", dto.Name)
+	require.Equal(t, "image/png", dto.MimeType)
+
+	file, ok := fileRepository.files[dto.ID]
+	require.True(t, ok)
+	require.True(t, file.Processing)
+	require.NotEmpty(t, file.Checksum)
+
+	// Images are enqueued for processing
+	require.Equal(t, dto.ID, <-service.queue)
+}
+
+func TestUploadFileLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		err     error
+	}{
+		{name: "too large", content: bytes.Repeat([]byte{0}, 1025), err: filedomain.ErrFileTooLarge()},
+		{name: "type not allowed", content: []byte("plain text"), err: filedomain.ErrFileTypeNotAllowed()},
+		{name: "empty", err: filedomain.ErrFileTypeNotAllowed()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, fileRepository := newTestService(t)
+
+			_, err := service.UploadFile(context.Background(), domain.ID(), newTestFileHeader(t, "file", test.content))
+			require.Equal(t, test.err, err)
+			require.Empty(t, fileRepository.files)
+		})
+	}
+}
+
+func TestUploadFileQueueFull(t *testing.T) {
+	service, fileRepository := newTestService(t)
+
+	// Queue has space for one file only, other uploads must not wait for processing
+	for i := 0; i < 3; i++ {
+		_, err := service.UploadFile(context.Background(), domain.ID(), newTestFileHeader(t, "pixel.png", newTestPNG(t)))
+		require.NoError(t, err)
+	}
+
+	require.Len(t, fileRepository.files, 3)
+	require.Len(t, service.queue, 1)
+}
+
+func TestProcessFileClaim(t *testing.T) {
+	service, fileRepository := newTestService(t)
+
+	dto, err := service.UploadFile(context.Background(), domain.ID(), newTestFileHeader(t, "pixel.png", newTestPNG(t)))
+	require.NoError(t, err)
+
+	// File is claimed by other instance
+	lockedUntil := time.Now().Add(time.Minute)
+
+	file := fileRepository.files[dto.ID]
+	file.LockedUntil = &lockedUntil
+	fileRepository.files[dto.ID] = file
+
+	service.processFile(context.Background(), dto.ID)
+	require.True(t, fileRepository.files[dto.ID].Processing)
+
+	// Lease of other instance is expired
+	lockedUntil = time.Now().Add(-time.Second)
+	fileRepository.files[dto.ID] = file
+
+	service.processFile(context.Background(), dto.ID)
+
+	file = fileRepository.files[dto.ID]
+	require.False(t, file.Processing)
+	require.Nil(t, file.LockedUntil)
+	require.Equal(t, 1, file.Width)
+	require.Equal(t, 1, file.Height)
+
+	// Processed file is not claimed again
+	_, err = fileRepository.Claim(context.Background(), dto.ID, time.Now(), time.Now().Add(time.Minute))
+	require.ErrorIs(t, err, mongo.ErrNoDocuments)
+}
@@ -27,7 +27,8 @@ func NewClient(config *config.Centrifugo) *Client {
 }
 
 const (
-	publishMethod = "publish"
+	publishMethod   = "publish"
+	broadcastMethod = "broadcast"
 )
 
 type PublishRequest struct {
@@ -64,6 +65,40 @@ func (c *Client) Publish(ctx context.Context, channel string, data any) (*Publis
 	return result, nil
 }
 
+type BroadcastRequest struct {
+	Channels []string `json:"channels"`
+	Data     any      `json:"data"`
+}
+
+type BroadcastResponse struct {
+	Responses []Response[PublishResponse] `json:"responses"`
+}
+
+// Broadcast publishes the same data into many channels with a single request
+func (c *Client) Broadcast(ctx context.Context, channels []string, data any) (*BroadcastResponse, error) {
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetBody(&Request[BroadcastRequest]{
+			Method: broadcastMethod,
+			Params: BroadcastRequest{
+				Channels: channels,
+				Data:     data,
+			},
+		}).
+		SetResult(&Response[BroadcastResponse]{}).
+		Post("")
+	if err != nil {
+		return nil, fmt.Errorf("centrifugo: %v", err)
+	}
+
+	result, err := handleResponse[BroadcastResponse](resp)
+	if err != nil {
+		return nil, fmt.Errorf("centrifugo: %v", err)
+	}
+
+	return result, nil
+}
+
 func handleResponse[R any](resp *resty.Response) (*R, error) {
 	if resp.IsError() {
 		return nil, &HttpError{
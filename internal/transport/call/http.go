@@ -29,6 +29,7 @@ func (e *HttpEndpoint) Register(group *gin.RouterGroup) {
 		Use(authtransport.NewHttpAuthMiddleware(e.jwtService))
 	{
 		callGroup.GET("", e.getCall)
+		callGroup.GET("/history", e.listCalls)
 		callGroup.PUT("/:id/status", e.updateCallStatus)
 		callGroup.PUT("/:id/data", e.proxyCallData)
 
@@ -70,6 +71,29 @@ func (e *HttpEndpoint) getCall(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, call)
 }
 
+func (e *HttpEndpoint) listCalls(ctx *gin.Context) {
+	var query calldomain.ListCallsRequestQuery
+
+	if !transport.HttpBindQuery(ctx, &query) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	calls, err := e.service.ListCalls(ctx, userID, query.PeerID, query.Pagination())
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, calldomain.ListCallsResponse{
+		Items:      calls.Items,
+		NextCursor: calls.NextCursor,
+		PrevCursor: calls.PrevCursor,
+	})
+}
+
 func (e *HttpEndpoint) updateCallStatus(ctx *gin.Context) {
 	var (
 		params domain.IdParam
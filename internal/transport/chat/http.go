@@ -2,6 +2,7 @@ package chattransport
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/undefined7887/harmony-backend/internal/domain"
@@ -10,6 +11,7 @@ import (
 	jwtservice "github.com/undefined7887/harmony-backend/internal/service/jwt"
 	"github.com/undefined7887/harmony-backend/internal/transport"
 	authtransport "github.com/undefined7887/harmony-backend/internal/transport/auth"
+	"github.com/undefined7887/harmony-backend/internal/util"
 )
 
 type HttpEndpoint struct {
@@ -30,14 +32,68 @@ func (e *HttpEndpoint) Register(group *gin.RouterGroup) {
 		Use(authtransport.NewHttpAuthMiddleware(e.jwtService))
 	{
 		chatGroup.GET("", e.listChats)
+		chatGroup.GET("/search", e.searchMessages)
+
+		chatGroup.GET("/scheduled", e.listScheduledMessages)
+		chatGroup.PUT("/scheduled/:id", e.updateScheduledMessage)
+		chatGroup.DELETE("/scheduled/:id", e.deleteScheduledMessage)
+
+		chatGroup.GET("/folders", e.listFolders)
+		chatGroup.POST("/folders", e.createFolder)
+		chatGroup.GET("/folders/unread", e.listFoldersUnread)
+		chatGroup.PUT("/folders/:id", e.updateFolder)
+		chatGroup.DELETE("/folders/:id", e.deleteFolder)
 
 		chatGroup.POST("/:peer_type/:peer_id", e.createMessage)
 		chatGroup.GET("/:peer_type/:peer_id", e.listMessages)
 		chatGroup.PUT("/:peer_type/:peer_id/read", e.updateChatRead)
 		chatGroup.PUT("/:peer_type/:peer_id/typing", e.updateChatTyping)
+		chatGroup.PUT("/:peer_type/:peer_id/settings", e.updateChatSettings)
+
+		chatGroup.GET("/:peer_type/:peer_id/ttl", e.getChatTTL)
+		chatGroup.PUT("/:peer_type/:peer_id/ttl", e.updateChatTTL)
+
+		chatGroup.GET("/:peer_type/:peer_id/draft", e.getDraft)
+		chatGroup.PUT("/:peer_type/:peer_id/draft", e.updateDraft)
+		chatGroup.DELETE("/:peer_type/:peer_id/draft", e.deleteDraft)
+
+		chatGroup.GET("/:peer_type/:peer_id/pins", e.listPins)
+		chatGroup.PUT("/:peer_type/:peer_id/pins/:message_id", e.pinMessage)
+		chatGroup.DELETE("/:peer_type/:peer_id/pins/:message_id", e.unpinMessage)
 
 		chatGroup.GET("/message/:id", e.getMessage)
 		chatGroup.PUT("/message/:id", e.updateMessage)
+		chatGroup.DELETE("/message/:id", e.deleteMessage)
+		chatGroup.GET("/message/:id/thread", e.listThreadMessages)
+		chatGroup.GET("/message/:id/history", e.getMessageHistory)
+		chatGroup.GET("/message/:id/receipts", e.listMessageReceipts)
+		chatGroup.POST("/message/delivered", e.ackDelivery)
+		chatGroup.GET("/message/:id/attachments/:file_id", e.getMessageAttachment)
+		chatGroup.POST("/message/:id/forward", e.forwardMessage)
+		chatGroup.PUT("/message/:id/reactions/:emoji", e.addMessageReaction)
+		chatGroup.DELETE("/message/:id/reactions/:emoji", e.removeMessageReaction)
+		chatGroup.GET("/message/:id/poll/votes", e.listPollVotes)
+		chatGroup.PUT("/message/:id/poll/vote", e.votePoll)
+		chatGroup.DELETE("/message/:id/poll/vote", e.retractPollVote)
+
+		// Group routes share '/:peer_type/:peer_id' prefix with chat routes,
+		// so paths like '/group/:id/info' don't shadow group messages listing
+		chatGroup.POST("/group", e.createGroup)
+		chatGroup.GET("/:peer_type/:peer_id/info", e.getGroup)
+		chatGroup.PUT("/:peer_type/:peer_id/info", e.updateGroup)
+		chatGroup.POST("/:peer_type/:peer_id/leave", e.leaveGroup)
+		chatGroup.PUT("/:peer_type/:peer_id/owner", e.transferGroupOwnership)
+
+		chatGroup.GET("/:peer_type/:peer_id/members", e.listGroupMembers)
+		chatGroup.POST("/:peer_type/:peer_id/members", e.addGroupMember)
+		chatGroup.DELETE("/:peer_type/:peer_id/members/:user_id", e.removeGroupMember)
+		chatGroup.PUT("/:peer_type/:peer_id/members/:user_id/role", e.updateGroupMemberRole)
+
+		chatGroup.GET("/:peer_type/:peer_id/invites", e.listGroupInvites)
+		chatGroup.POST("/:peer_type/:peer_id/invites", e.createGroupInvite)
+		chatGroup.DELETE("/:peer_type/:peer_id/invites/:token", e.deleteGroupInvite)
+
+		chatGroup.POST("/invite/:token", e.joinGroup)
 	}
 }
 
@@ -53,12 +109,51 @@ func (e *HttpEndpoint) createMessage(ctx *gin.Context) {
 
 	userID := authtransport.GetClaims(ctx).Subject
 
+	if body.SendAt != nil {
+		e.scheduleMessage(ctx, userID, params, body)
+
+		return
+	}
+
 	message, err := e.service.CreateMessage(
 		ctx,
 		userID,
 		params.PeerID,
 		params.PeerType,
 		body.Text,
+		body.AttachmentIDs,
+		body.Poll.NewPoll(),
+		body.ReplyToID,
+		body.ThreadRootID,
+	)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.CreateMessageResponse{
+		MessageID: message.ID,
+	})
+}
+
+func (e *HttpEndpoint) scheduleMessage(
+	ctx *gin.Context,
+	userID string,
+	params chatdomain.PeerParams,
+	body chatdomain.CreateMessageRequestBody,
+) {
+	message, err := e.service.ScheduleMessage(
+		ctx,
+		userID,
+		params.PeerID,
+		params.PeerType,
+		body.Text,
+		body.AttachmentIDs,
+		body.Poll.NewPoll(),
+		body.ReplyToID,
+		body.ThreadRootID,
+		*body.SendAt,
 	)
 	if err != nil {
 		transport.HttpHandleError(ctx, err)
@@ -71,6 +166,66 @@ func (e *HttpEndpoint) createMessage(ctx *gin.Context) {
 	})
 }
 
+func (e *HttpEndpoint) listScheduledMessages(ctx *gin.Context) {
+	var query chatdomain.ListScheduledMessagesRequestQuery
+
+	if !transport.HttpBindQuery(ctx, &query) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	messages, err := e.service.ListScheduledMessages(ctx, userID, query.PeerID, query.PeerType)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.ListScheduledMessagesResponse{
+		Items: messages,
+	})
+}
+
+func (e *HttpEndpoint) updateScheduledMessage(ctx *gin.Context) {
+	var (
+		params domain.IdParam
+		body   chatdomain.UpdateScheduledMessageRequestBody
+	)
+
+	if !transport.HttpBind(ctx, &params, &body, nil) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.UpdateScheduledMessage(ctx, userID, params.ID, body.Text, body.SendAt); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) deleteScheduledMessage(ctx *gin.Context) {
+	var params domain.IdParam
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.DeleteScheduledMessage(ctx, userID, params.ID); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
 func (e *HttpEndpoint) getMessage(ctx *gin.Context) {
 	var params domain.IdParam
 
@@ -92,6 +247,27 @@ func (e *HttpEndpoint) getMessage(ctx *gin.Context) {
 	})
 }
 
+func (e *HttpEndpoint) getMessageAttachment(ctx *gin.Context) {
+	var params chatdomain.AttachmentParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	file, err := e.service.GetMessageAttachment(ctx, userID, params.ID, params.FileID)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.GetAttachmentResponse{
+		FileDTO: file,
+	})
+}
+
 func (e *HttpEndpoint) listMessages(ctx *gin.Context) {
 	var (
 		params chatdomain.PeerParams
@@ -109,8 +285,7 @@ func (e *HttpEndpoint) listMessages(ctx *gin.Context) {
 		userID,
 		params.PeerID,
 		params.PeerType,
-		query.Offset,
-		query.Limit,
+		query.Pagination(),
 	)
 	if err != nil {
 		transport.HttpHandleError(ctx, err)
@@ -119,7 +294,99 @@ func (e *HttpEndpoint) listMessages(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, chatdomain.ListMessagesResponse{
-		Items: messages,
+		Items:      messages.Items,
+		NextCursor: messages.NextCursor,
+		PrevCursor: messages.PrevCursor,
+	})
+}
+
+func (e *HttpEndpoint) forwardMessage(ctx *gin.Context) {
+	var (
+		params domain.IdParam
+		body   chatdomain.ForwardMessageRequestBody
+	)
+
+	if !transport.HttpBind(ctx, &params, &body, nil) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	messages, err := e.service.ForwardMessage(ctx, userID, params.ID, body.Targets)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.ForwardMessageResponse{
+		MessageIDs: util.Map(messages, func(message chatdomain.MessageDTO) string {
+			return message.ID
+		}),
+	})
+}
+
+func (e *HttpEndpoint) listThreadMessages(ctx *gin.Context) {
+	var (
+		params domain.IdParam
+		query  domain.PaginationQuery
+	)
+
+	if !transport.HttpBind(ctx, &params, nil, &query) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	messages, err := e.service.ListThreadMessages(
+		ctx,
+		userID,
+		params.ID,
+		query.Pagination(),
+	)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.ListThreadMessagesResponse{
+		Items:      messages.Items,
+		NextCursor: messages.NextCursor,
+		PrevCursor: messages.PrevCursor,
+	})
+}
+
+func (e *HttpEndpoint) searchMessages(ctx *gin.Context) {
+	var query chatdomain.SearchMessagesRequestQuery
+
+	if !transport.HttpBindQuery(ctx, &query) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	results, err := e.service.SearchMessages(
+		ctx,
+		userID,
+		query.Text,
+		query.PeerID,
+		query.PeerType,
+		query.UserID,
+		query.From,
+		query.To,
+		query.Pagination(),
+	)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.SearchMessagesResponse{
+		Items:      results.Items,
+		NextCursor: results.NextCursor,
+		PrevCursor: results.PrevCursor,
 	})
 }
 
@@ -149,35 +416,29 @@ func (e *HttpEndpoint) updateMessage(ctx *gin.Context) {
 	ctx.Status(http.StatusNoContent)
 }
 
-func (e *HttpEndpoint) listChats(ctx *gin.Context) {
-	var query chatdomain.ListChatsRequestQuery
+func (e *HttpEndpoint) getMessageHistory(ctx *gin.Context) {
+	var params domain.IdParam
 
-	if !transport.HttpBindQuery(ctx, &query) {
+	if !transport.HttpBindURI(ctx, &params) {
 		return
 	}
 
 	userID := authtransport.GetClaims(ctx).Subject
 
-	chats, err := e.service.ListChats(
-		ctx,
-		userID,
-		query.PeerType,
-		query.Offset,
-		query.Limit,
-	)
+	revisions, err := e.service.GetMessageHistory(ctx, userID, params.ID)
 	if err != nil {
 		transport.HttpHandleError(ctx, err)
 
 		return
 	}
 
-	ctx.JSON(http.StatusOK, chatdomain.ListChatsResponse{
-		Items: chats,
+	ctx.JSON(http.StatusOK, chatdomain.GetMessageHistoryResponse{
+		Revisions: revisions,
 	})
 }
 
-func (e *HttpEndpoint) updateChatRead(ctx *gin.Context) {
-	var params chatdomain.PeerParams
+func (e *HttpEndpoint) listMessageReceipts(ctx *gin.Context) {
+	var params domain.IdParam
 
 	if !transport.HttpBindURI(ctx, &params) {
 		return
@@ -185,7 +446,29 @@ func (e *HttpEndpoint) updateChatRead(ctx *gin.Context) {
 
 	userID := authtransport.GetClaims(ctx).Subject
 
-	if err := e.service.UpdateChatRead(ctx, userID, params.PeerID, params.PeerType); err != nil {
+	deliveredTo, readBy, err := e.service.ListMessageReceipts(ctx, userID, params.ID)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.ListMessageReceiptsResponse{
+		DeliveredTo: deliveredTo,
+		ReadBy:      readBy,
+	})
+}
+
+func (e *HttpEndpoint) ackDelivery(ctx *gin.Context) {
+	var body chatdomain.AckDeliveryRequestBody
+
+	if !transport.HttpBindJSON(ctx, &body) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.AckDelivery(ctx, userID, body.MessageIDs); err != nil {
 		transport.HttpHandleError(ctx, err)
 
 		return
@@ -194,10 +477,78 @@ func (e *HttpEndpoint) updateChatRead(ctx *gin.Context) {
 	ctx.Status(http.StatusNoContent)
 }
 
-func (e *HttpEndpoint) updateChatTyping(ctx *gin.Context) {
+func (e *HttpEndpoint) deleteMessage(ctx *gin.Context) {
 	var (
-		params chatdomain.PeerParams
-		body   chatdomain.UpdateChatTypingRequestBody
+		params domain.IdParam
+		query  chatdomain.DeleteMessageRequestQuery
+	)
+
+	if !transport.HttpBind(ctx, &params, nil, &query) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.DeleteMessage(ctx, userID, params.ID, query.ForEveryone); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) addMessageReaction(ctx *gin.Context) {
+	e.updateMessageReaction(ctx, true)
+}
+
+func (e *HttpEndpoint) removeMessageReaction(ctx *gin.Context) {
+	e.updateMessageReaction(ctx, false)
+}
+
+func (e *HttpEndpoint) updateMessageReaction(ctx *gin.Context, reacted bool) {
+	var params chatdomain.ReactionParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.UpdateMessageReaction(ctx, userID, params.ID, params.Emoji, reacted); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) listPollVotes(ctx *gin.Context) {
+	var params domain.IdParam
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	votes, err := e.service.ListPollVotes(ctx, userID, params.ID)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.ListPollVotesResponse{
+		Items: votes,
+	})
+}
+
+func (e *HttpEndpoint) votePoll(ctx *gin.Context) {
+	var (
+		params domain.IdParam
+		body   chatdomain.VotePollRequestBody
 	)
 
 	if !transport.HttpBind(ctx, &params, &body, nil) {
@@ -206,13 +557,7 @@ func (e *HttpEndpoint) updateChatTyping(ctx *gin.Context) {
 
 	userID := authtransport.GetClaims(ctx).Subject
 
-	if err := e.service.UpdateChatTyping(
-		ctx,
-		userID,
-		params.PeerID,
-		params.PeerType,
-		body.Typing,
-	); err != nil {
+	if err := e.service.VotePoll(ctx, userID, params.ID, body.OptionIDs); err != nil {
 		transport.HttpHandleError(ctx, err)
 
 		return
@@ -220,3 +565,690 @@ func (e *HttpEndpoint) updateChatTyping(ctx *gin.Context) {
 
 	ctx.Status(http.StatusNoContent)
 }
+
+func (e *HttpEndpoint) retractPollVote(ctx *gin.Context) {
+	var params domain.IdParam
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.RetractPollVote(ctx, userID, params.ID); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) listChats(ctx *gin.Context) {
+	var query chatdomain.ListChatsRequestQuery
+
+	if !transport.HttpBindQuery(ctx, &query) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	pinned, chats, err := e.service.ListChats(
+		ctx,
+		userID,
+		query.FolderID,
+		chatdomain.ChatFilter{
+			PeerType: query.PeerType,
+			Archived: query.Archived,
+			Muted:    query.Muted,
+		},
+		query.Pagination(),
+	)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.ListChatsResponse{
+		Pinned:     pinned,
+		Items:      chats.Items,
+		NextCursor: chats.NextCursor,
+		PrevCursor: chats.PrevCursor,
+	})
+}
+
+func (e *HttpEndpoint) updateChatRead(ctx *gin.Context) {
+	var (
+		params chatdomain.PeerParams
+		query  chatdomain.UpdateChatReadRequestQuery
+	)
+
+	if !transport.HttpBind(ctx, &params, nil, &query) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.UpdateChatRead(ctx, userID, params.PeerID, params.PeerType, query.MessageID); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) updateChatSettings(ctx *gin.Context) {
+	var (
+		params chatdomain.PeerParams
+		body   chatdomain.UpdateChatSettingsRequestBody
+	)
+
+	if !transport.HttpBind(ctx, &params, &body, nil) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.UpdateChatSettings(
+		ctx,
+		userID,
+		params.PeerID,
+		params.PeerType,
+		chatdomain.ChatSettings{
+			MutedUntil: body.MutedUntil,
+			Archived:   body.Archived,
+			PinOrder:   body.PinOrder,
+		},
+	); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) getChatTTL(ctx *gin.Context) {
+	var params chatdomain.PeerParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	ttl, err := e.service.GetChatTTL(ctx, userID, params.PeerID, params.PeerType)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.GetChatTTLResponse{
+		ChatTTLDTO: ttl,
+	})
+}
+
+func (e *HttpEndpoint) updateChatTTL(ctx *gin.Context) {
+	var (
+		params chatdomain.PeerParams
+		body   chatdomain.UpdateChatTTLRequestBody
+	)
+
+	if !transport.HttpBind(ctx, &params, &body, nil) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.UpdateChatTTL(
+		ctx,
+		userID,
+		params.PeerID,
+		params.PeerType,
+		time.Duration(body.TTL)*time.Second,
+	); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) getDraft(ctx *gin.Context) {
+	var params chatdomain.PeerParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	draft, err := e.service.GetDraft(ctx, userID, params.PeerID, params.PeerType)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.GetDraftResponse{
+		DraftDTO: draft,
+	})
+}
+
+func (e *HttpEndpoint) updateDraft(ctx *gin.Context) {
+	var (
+		params chatdomain.PeerParams
+		body   chatdomain.UpdateDraftRequestBody
+	)
+
+	if !transport.HttpBind(ctx, &params, &body, nil) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.UpdateDraft(
+		ctx,
+		userID,
+		params.PeerID,
+		params.PeerType,
+		body.Text,
+		body.ReplyToID,
+	); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) deleteDraft(ctx *gin.Context) {
+	var params chatdomain.PeerParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.DeleteDraft(ctx, userID, params.PeerID, params.PeerType); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) updateChatTyping(ctx *gin.Context) {
+	var (
+		params chatdomain.PeerParams
+		body   chatdomain.UpdateChatTypingRequestBody
+	)
+
+	if !transport.HttpBind(ctx, &params, &body, nil) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.UpdateChatTyping(
+		ctx,
+		userID,
+		params.PeerID,
+		params.PeerType,
+		body.Typing,
+	); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) listPins(ctx *gin.Context) {
+	var params chatdomain.PeerParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	pins, err := e.service.ListPins(ctx, userID, params.PeerID, params.PeerType)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.ListPinsResponse{
+		Items: pins,
+	})
+}
+
+func (e *HttpEndpoint) pinMessage(ctx *gin.Context) {
+	var params chatdomain.PinParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.PinMessage(ctx, userID, params.PeerID, params.PeerType, params.MessageID); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) unpinMessage(ctx *gin.Context) {
+	var params chatdomain.PinParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.UnpinMessage(ctx, userID, params.PeerID, params.PeerType, params.MessageID); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) createGroup(ctx *gin.Context) {
+	var body chatdomain.CreateGroupRequestBody
+
+	if !transport.HttpBindJSON(ctx, &body) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	group, err := e.service.CreateGroup(ctx, userID, body.Name, body.UserIDs)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.CreateGroupResponse{
+		GroupID: group.ID,
+	})
+}
+
+func (e *HttpEndpoint) getGroup(ctx *gin.Context) {
+	var params chatdomain.GroupParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	group, err := e.service.GetGroup(ctx, userID, params.PeerID)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.GetGroupResponse{
+		GroupDTO: group,
+	})
+}
+
+func (e *HttpEndpoint) updateGroup(ctx *gin.Context) {
+	var (
+		params chatdomain.GroupParams
+		body   chatdomain.UpdateGroupRequestBody
+	)
+
+	if !transport.HttpBind(ctx, &params, &body, nil) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if _, err := e.service.UpdateGroup(ctx, userID, params.PeerID, body.Name); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) leaveGroup(ctx *gin.Context) {
+	var params chatdomain.GroupParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.LeaveGroup(ctx, userID, params.PeerID); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) transferGroupOwnership(ctx *gin.Context) {
+	var (
+		params chatdomain.GroupParams
+		body   chatdomain.TransferGroupOwnershipRequestBody
+	)
+
+	if !transport.HttpBind(ctx, &params, &body, nil) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.TransferGroupOwnership(ctx, userID, params.PeerID, body.UserID); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) listGroupMembers(ctx *gin.Context) {
+	var params chatdomain.GroupParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	members, err := e.service.ListGroupMembers(ctx, userID, params.PeerID)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.ListGroupMembersResponse{
+		Items: members,
+	})
+}
+
+func (e *HttpEndpoint) addGroupMember(ctx *gin.Context) {
+	var (
+		params chatdomain.GroupParams
+		body   chatdomain.AddGroupMemberRequestBody
+	)
+
+	if !transport.HttpBind(ctx, &params, &body, nil) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.AddGroupMember(ctx, userID, params.PeerID, body.UserID); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) removeGroupMember(ctx *gin.Context) {
+	var params chatdomain.GroupMemberParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.RemoveGroupMember(ctx, userID, params.PeerID, params.UserID); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) updateGroupMemberRole(ctx *gin.Context) {
+	var (
+		params chatdomain.GroupMemberParams
+		body   chatdomain.UpdateGroupMemberRoleRequestBody
+	)
+
+	if !transport.HttpBind(ctx, &params, &body, nil) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.UpdateGroupMemberRole(
+		ctx,
+		userID,
+		params.PeerID,
+		params.UserID,
+		body.Role,
+	); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) listGroupInvites(ctx *gin.Context) {
+	var params chatdomain.GroupParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	invites, err := e.service.ListGroupInvites(ctx, userID, params.PeerID)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.ListGroupInvitesResponse{
+		Items: invites,
+	})
+}
+
+func (e *HttpEndpoint) createGroupInvite(ctx *gin.Context) {
+	var (
+		params chatdomain.GroupParams
+		body   chatdomain.CreateGroupInviteRequestBody
+	)
+
+	if !transport.HttpBind(ctx, &params, &body, nil) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	invite, err := e.service.CreateGroupInvite(
+		ctx,
+		userID,
+		params.PeerID,
+		time.Duration(body.Lifetime)*time.Second,
+		body.MaxUses,
+	)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.CreateGroupInviteResponse{
+		GroupInviteDTO: invite,
+	})
+}
+
+func (e *HttpEndpoint) deleteGroupInvite(ctx *gin.Context) {
+	var params chatdomain.GroupInviteParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.DeleteGroupInvite(ctx, userID, params.PeerID, params.Token); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) joinGroup(ctx *gin.Context) {
+	var params chatdomain.JoinGroupRequestParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	groupID, err := e.service.JoinGroup(ctx, userID, params.Token)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.JoinGroupResponse{
+		GroupID: groupID,
+	})
+}
+
+func (e *HttpEndpoint) createFolder(ctx *gin.Context) {
+	var body chatdomain.FolderRequestBody
+
+	if !transport.HttpBindJSON(ctx, &body) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	folderID, err := e.service.CreateFolder(ctx, userID, mapFolderRequestBody(body))
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.CreateFolderResponse{
+		FolderID: folderID,
+	})
+}
+
+func (e *HttpEndpoint) listFolders(ctx *gin.Context) {
+	userID := authtransport.GetClaims(ctx).Subject
+
+	folders, err := e.service.ListFolders(ctx, userID)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.ListFoldersResponse{
+		Items: folders,
+	})
+}
+
+func (e *HttpEndpoint) listFoldersUnread(ctx *gin.Context) {
+	userID := authtransport.GetClaims(ctx).Subject
+
+	unread, err := e.service.ListFoldersUnread(ctx, userID)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, chatdomain.ListFoldersUnreadResponse{
+		Items: unread,
+	})
+}
+
+func (e *HttpEndpoint) updateFolder(ctx *gin.Context) {
+	var (
+		params chatdomain.FolderParams
+		body   chatdomain.FolderRequestBody
+	)
+
+	if !transport.HttpBind(ctx, &params, &body, nil) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.UpdateFolder(ctx, userID, params.ID, mapFolderRequestBody(body)); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (e *HttpEndpoint) deleteFolder(ctx *gin.Context) {
+	var params chatdomain.FolderParams
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	if err := e.service.DeleteFolder(ctx, userID, params.ID); err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func mapFolderRequestBody(body chatdomain.FolderRequestBody) chatdomain.Folder {
+	return chatdomain.Folder{
+		Name: body.Name,
+		Chats: util.Map(body.Chats, func(chat chatdomain.FolderChatDTO) chatdomain.FolderChat {
+			return chatdomain.FolderChat{
+				PeerID:   chat.PeerID,
+				PeerType: chat.PeerType,
+			}
+		}),
+		PeerTypes: body.PeerTypes,
+		Unread:    body.Unread,
+		UserIDs:   body.UserIDs,
+	}
+}
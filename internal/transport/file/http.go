@@ -0,0 +1,132 @@
+package filetransport
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/undefined7887/harmony-backend/internal/config"
+	"github.com/undefined7887/harmony-backend/internal/domain"
+	filedomain "github.com/undefined7887/harmony-backend/internal/domain/file"
+	"github.com/undefined7887/harmony-backend/internal/infrastructure/storage"
+	fileservice "github.com/undefined7887/harmony-backend/internal/service/file"
+	jwtservice "github.com/undefined7887/harmony-backend/internal/service/jwt"
+	"github.com/undefined7887/harmony-backend/internal/transport"
+	authtransport "github.com/undefined7887/harmony-backend/internal/transport/auth"
+)
+
+const (
+	// Reserved for multipart headers and boundaries
+	multipartOverhead = 1 << 20
+
+	formFileKey = "file"
+)
+
+type HttpEndpoint struct {
+	config     *config.File
+	service    *fileservice.Service
+	jwtService *jwtservice.Service
+}
+
+func NewHttpEndpoint(config *config.File, service *fileservice.Service, jwtService *jwtservice.Service) transport.HttpEndpoint {
+	return &HttpEndpoint{
+		config:     config,
+		service:    service,
+		jwtService: jwtService,
+	}
+}
+
+func (e *HttpEndpoint) Register(group *gin.RouterGroup) {
+	fileGroup := group.Group("/file")
+	{
+		// Authorized by url signature
+		fileGroup.GET("/:id/download", e.downloadFile)
+	}
+
+	authFileGroup := fileGroup.
+		Group("").
+		Use(authtransport.NewHttpAuthMiddleware(e.jwtService))
+	{
+		authFileGroup.POST("", e.uploadFile)
+		authFileGroup.GET("/:id", e.getFile)
+	}
+}
+
+func (e *HttpEndpoint) uploadFile(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, e.config.MaxSize+multipartOverhead)
+
+	header, err := ctx.FormFile(formFileKey)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+
+		if errors.As(err, &maxBytesErr) {
+			transport.HttpHandleError(ctx, filedomain.ErrFileTooLarge())
+		} else {
+			transport.HttpHandleError(ctx, domain.ErrBadRequest(err))
+		}
+
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	file, err := e.service.UploadFile(ctx, userID, header)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, filedomain.UploadFileResponse{
+		FileDTO: file,
+	})
+}
+
+func (e *HttpEndpoint) getFile(ctx *gin.Context) {
+	var params domain.IdParam
+
+	if !transport.HttpBindURI(ctx, &params) {
+		return
+	}
+
+	userID := authtransport.GetClaims(ctx).Subject
+
+	file, err := e.service.GetFile(ctx, userID, params.ID)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, filedomain.GetFileResponse{
+		FileDTO: file,
+	})
+}
+
+func (e *HttpEndpoint) downloadFile(ctx *gin.Context) {
+	var (
+		params domain.IdParam
+		query  filedomain.DownloadFileRequestQuery
+	)
+
+	if !transport.HttpBind(ctx, &params, nil, &query) {
+		return
+	}
+
+	file, reader, err := e.service.DownloadFile(ctx, params.ID, query.Thumbnail, query.Expires, query.Signature)
+	if err != nil {
+		transport.HttpHandleError(ctx, err)
+
+		return
+	}
+
+	defer reader.Close()
+
+	ctx.DataFromReader(http.StatusOK, file.Size, file.MimeType, reader, map[string]string{
+		"Content-Disposition": storage.ContentDisposition(file.Name),
+
+		// Browsers must not guess content type of user files
+		"X-Content-Type-Options": "nosniff",
+	})
+}
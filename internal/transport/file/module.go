@@ -0,0 +1,10 @@
+package filetransport
+
+import "go.uber.org/fx"
+
+var Module = fx.Provide(
+	fx.Annotated{
+		Group:  "http_endpoints",
+		Target: NewHttpEndpoint,
+	},
+)
@@ -21,4 +21,16 @@ func init() {
 	if err := engine.RegisterValidation(IdTag, validateID); err != nil {
 		panic(fmt.Sprintf("validation: unexpected \"%s\" registration error: %v", IdTag, err))
 	}
+
+	if err := engine.RegisterValidation(TokenTag, validateToken); err != nil {
+		panic(fmt.Sprintf("validation: unexpected \"%s\" registration error: %v", TokenTag, err))
+	}
+
+	if err := engine.RegisterValidation(EmojiTag, validateEmoji); err != nil {
+		panic(fmt.Sprintf("validation: unexpected \"%s\" registration error: %v", EmojiTag, err))
+	}
+
+	if err := engine.RegisterValidation(CursorTag, validateCursor); err != nil {
+		panic(fmt.Sprintf("validation: unexpected \"%s\" registration error: %v", CursorTag, err))
+	}
 }
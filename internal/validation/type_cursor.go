@@ -0,0 +1,22 @@
+package validation
+
+import (
+	"github.com/go-playground/validator/v10"
+
+	"github.com/undefined7887/harmony-backend/internal/domain"
+)
+
+const (
+	CursorTag = "cursor"
+)
+
+func validateCursor(value validator.FieldLevel) bool {
+	val, ok := value.Field().Interface().(string)
+	if !ok {
+		return false
+	}
+
+	_, err := domain.ParseCursor(val)
+
+	return err == nil
+}
@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	EmojiTag = "emoji"
+
+	// Long enough for ZWJ sequences like family emojis
+	EmojiMaxSize = 32
+)
+
+func validateEmoji(value validator.FieldLevel) bool {
+	val, ok := value.Field().Interface().(string)
+
+	return ok && IsEmoji(val)
+}
+
+// IsEmoji checks that value is a single emoji or emoji sequence.
+// Emojis are used as keys in mongo documents, so only symbols and emoji modifiers are allowed
+func IsEmoji(value string) bool {
+	if value == "" || len(value) > EmojiMaxSize || !utf8.ValidString(value) {
+		return false
+	}
+
+	hasSymbol := false
+
+	for _, r := range value {
+		switch {
+		case unicode.Is(unicode.So, r):
+			hasSymbol = true
+
+		case isEmojiModifier(r):
+			// Allowed only together with symbols
+
+		default:
+			return false
+		}
+	}
+
+	return hasSymbol
+}
+
+func isEmojiModifier(r rune) bool {
+	return r == 0x200D || // Zero width joiner
+		r == 0xFE0E || r == 0xFE0F || // Variation selectors
+		(r >= 0x1F3FB && r <= 0x1F3FF) || // Skin tones
+		(r >= 0xE0020 && r <= 0xE007F) // Tags
+}
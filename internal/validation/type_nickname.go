@@ -12,11 +12,14 @@ const (
 )
 
 var (
-	Nickname       = "^[A-z0-9-_.]{4,30}$"
-	NicknameRegexp = regexp.MustCompile(Nickname)
+	Nickname       = "[A-z0-9-_.]{4,30}"
+	NicknameRegexp = regexp.MustCompile("^" + Nickname + "$")
 
-	NicknameExtended       = "^[A-z0-9-_.]{4,30}#[0-9]{4}$"
-	NicknameExtendedRegexp = regexp.MustCompile(NicknameExtended)
+	NicknameExtended       = Nickname + "#[0-9]{4}"
+	NicknameExtendedRegexp = regexp.MustCompile("^" + NicknameExtended + "$")
+
+	// MentionRegexp finds '@nickname#1234' tokens in text, first submatch is extended nickname
+	MentionRegexp = regexp.MustCompile("@(" + NicknameExtended + ")\\b")
 )
 
 func validateNickname(value validator.FieldLevel) bool {
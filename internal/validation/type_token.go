@@ -0,0 +1,22 @@
+package validation
+
+import (
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	TokenTag = "token"
+)
+
+var (
+	Token       = "^[A-z0-9-_]{86}$"
+	TokenRegexp = regexp.MustCompile(Token)
+)
+
+func validateToken(value validator.FieldLevel) bool {
+	val, ok := value.Field().Interface().(string)
+
+	return ok && TokenRegexp.MatchString(val)
+}